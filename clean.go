@@ -0,0 +1,154 @@
+package goelement
+
+import "golang.org/x/net/html"
+
+// Cleanser describes a set of policies for sanitizing a Node tree: which
+// tags to drop entirely, which to unwrap, which to keep, which to
+// rename, and which attributes to strip. Build one with NewCleanser and
+// pass it to Node.Clean.
+type Cleanser struct {
+	removeTags   map[string]bool
+	removeAttrs  map[string]bool
+	keepOnlyTags map[string]bool
+	unwrapTags   map[string]bool
+	convertTags  map[string]string
+}
+
+// NewCleanser returns an empty Cleanser with no policies configured.
+func NewCleanser() *Cleanser {
+	return &Cleanser{}
+}
+
+// RemoveTags drops elements with any of the given tag names, along with
+// their entire subtree.
+func (c *Cleanser) RemoveTags(tags []string) *Cleanser {
+	if c.removeTags == nil {
+		c.removeTags = make(map[string]bool, len(tags))
+	}
+	for _, tag := range tags {
+		c.removeTags[tag] = true
+	}
+	return c
+}
+
+// RemoveAttrs strips the given attribute names from every remaining
+// element.
+func (c *Cleanser) RemoveAttrs(attrs []string) *Cleanser {
+	if c.removeAttrs == nil {
+		c.removeAttrs = make(map[string]bool, len(attrs))
+	}
+	for _, attr := range attrs {
+		c.removeAttrs[attr] = true
+	}
+	return c
+}
+
+// KeepOnlyTags unwraps every element whose tag isn't in the given list,
+// keeping its children in its place. With none set, every tag is kept.
+func (c *Cleanser) KeepOnlyTags(tags []string) *Cleanser {
+	if c.keepOnlyTags == nil {
+		c.keepOnlyTags = make(map[string]bool, len(tags))
+	}
+	for _, tag := range tags {
+		c.keepOnlyTags[tag] = true
+	}
+	return c
+}
+
+// UnwrapTags removes elements with any of the given tag names but keeps
+// their children in their place, useful for purely presentational
+// wrappers like <font> or <span>.
+func (c *Cleanser) UnwrapTags(tags []string) *Cleanser {
+	if c.unwrapTags == nil {
+		c.unwrapTags = make(map[string]bool, len(tags))
+	}
+	for _, tag := range tags {
+		c.unwrapTags[tag] = true
+	}
+	return c
+}
+
+// ConvertTags renames elements, e.g. {"b": "strong"} turns every <b> into
+// a <strong> with the same attributes and children.
+func (c *Cleanser) ConvertTags(mapping map[string]string) *Cleanser {
+	if c.convertTags == nil {
+		c.convertTags = make(map[string]string, len(mapping))
+	}
+	for from, to := range mapping {
+		c.convertTags[from] = to
+	}
+	return c
+}
+
+// Clean returns a copy of node with c's policies applied, leaving node
+// itself untouched. The copy of node is always kept, even when a remove,
+// unwrap or keep-only policy names its tag; the other policies still
+// apply to it.
+func (node *Node) Clean(c *Cleanser) *Node {
+	clone := node.clone()
+	c.apply(clone)
+	return clone
+}
+
+// clone deep-copies node and its descendants into a new, detached tree.
+func (node *Node) clone() *Node {
+	token := node.Token
+	token.Attr = append([]html.Attribute(nil), node.Token.Attr...)
+	copyNode := newNode(token, nil)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		copyNode.appendChildFast(child.clone())
+	}
+	return copyNode
+}
+
+// apply walks node's subtree in post-order, applying c's policies. Post-
+// order ensures a node's children are already settled before a removal
+// or unwrap changes its own place in the tree.
+func (c *Cleanser) apply(node *Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		c.apply(child)
+		child = next
+	}
+
+	if node.Type != html.StartTagToken && node.Type != html.SelfClosingTagToken {
+		return
+	}
+
+	tag := node.Data
+	if c.removeTags[tag] && node.Parent != nil {
+		node.Remove()
+		return
+	}
+	if len(c.keepOnlyTags) > 0 && !c.keepOnlyTags[tag] && node.Parent != nil {
+		node.unwrap()
+		return
+	}
+	if c.unwrapTags[tag] && node.Parent != nil {
+		node.unwrap()
+		return
+	}
+	if to, ok := c.convertTags[tag]; ok {
+		node.Data = to
+	}
+	for attr := range c.removeAttrs {
+		node.RemoveAttr(attr)
+	}
+}
+
+// unwrap replaces node with its children, preserving their order. A node
+// with no parent (the clone's own root) is left alone, since it has
+// nowhere to place its children.
+func (node *Node) unwrap() {
+	if node.Parent == nil {
+		return
+	}
+	children := make([]*Node, 0, len(node.Children))
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+	for _, child := range children {
+		node.InsertBefore(child)
+	}
+	node.Remove()
+}
@@ -0,0 +1,65 @@
+package goelement_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+)
+
+func TestCleanRemoveTagsAndAttrs(t *testing.T) {
+	root := goelement.ParseFromString(`<div><script>evil()</script><p>keep <b onclick="x()">me</b></p></div>`)
+	div := root.FindTag("div")
+
+	cleaned := div.Clean(goelement.NewCleanser().RemoveTags([]string{"script"}).RemoveAttrs([]string{"onclick"}))
+	got := cleaned.HTML()
+
+	if strings.Contains(got, "script") {
+		t.Fatalf("script tag survived cleaning: %s", got)
+	}
+	if strings.Contains(got, "onclick") {
+		t.Fatalf("onclick attribute survived cleaning: %s", got)
+	}
+	if !strings.Contains(got, "keep") {
+		t.Fatalf("expected text preserved: %s", got)
+	}
+	if root.FindTag("script") == nil {
+		t.Fatalf("Clean mutated the original tree: script tag is gone from root")
+	}
+}
+
+func TestCleanUnwrapAndKeepOnly(t *testing.T) {
+	root := goelement.ParseFromString(`<div><font color="red"><span>hi <b>there</b></span></font></div>`)
+	div := root.FindTag("div")
+
+	unwrapped := div.Clean(goelement.NewCleanser().UnwrapTags([]string{"font", "span"}))
+	got := unwrapped.HTML()
+	if strings.Contains(got, "font") || strings.Contains(got, "span") {
+		t.Fatalf("UnwrapTags left wrapper tags behind: %s", got)
+	}
+	if !strings.Contains(got, "<b>there</b>") {
+		t.Fatalf("UnwrapTags lost nested content: %s", got)
+	}
+
+	keptOnly := div.Clean(goelement.NewCleanser().KeepOnlyTags([]string{"b"}))
+	got = keptOnly.HTML()
+	if strings.Contains(got, "font") || strings.Contains(got, "span") {
+		t.Fatalf("KeepOnlyTags left disallowed tags behind: %s", got)
+	}
+	if !strings.Contains(got, "<b>there</b>") {
+		t.Fatalf("KeepOnlyTags lost the allowed tag: %s", got)
+	}
+}
+
+func TestCleanConvertTags(t *testing.T) {
+	root := goelement.ParseFromString(`<div><b>bold</b></div>`)
+	div := root.FindTag("div")
+
+	cleaned := div.Clean(goelement.NewCleanser().ConvertTags(map[string]string{"b": "strong"}))
+	if got := cleaned.HTML(); !strings.Contains(got, "<strong>bold</strong>") {
+		t.Fatalf("ConvertTags didn't rename the tag: %s", got)
+	}
+	if got := div.HTML(); !strings.Contains(got, "<b>bold</b>") {
+		t.Fatalf("original node was mutated: %s", got)
+	}
+}
@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cacheEntry is one cached response, kept in memory for the life of the
+// Collector and mirrored to disk when Cache is set.
+type cacheEntry struct {
+	status int
+	body   []byte
+}
+
+// fetch retrieves u, preferring the in-memory cache, then the on-disk
+// cache, before finally making a real HTTP request.
+func (c *Collector) fetch(u *url.URL) ([]byte, int, http.Header, error) {
+	key := u.String()
+
+	if entry, ok := c.readMemCache(key); ok {
+		return entry.body, entry.status, nil, nil
+	}
+	if c.cacheDir != "" {
+		if entry, ok := c.readDiskCache(key); ok {
+			c.writeMemCache(key, entry)
+			return entry.body, entry.status, nil, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if c.Headers != nil {
+		req.Header = c.Headers.Clone()
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for _, cookie := range c.Cookies {
+		req.AddCookie(cookie)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, resp.Header, err
+	}
+
+	entry := cacheEntry{status: resp.StatusCode, body: body}
+	c.writeMemCache(key, entry)
+	if c.cacheDir != "" {
+		c.writeDiskCache(key, entry)
+	}
+	return body, resp.StatusCode, resp.Header, nil
+}
+
+func (c *Collector) readMemCache(key string) (cacheEntry, bool) {
+	c.memCacheMu.Lock()
+	defer c.memCacheMu.Unlock()
+	entry, ok := c.memCache[key]
+	return entry, ok
+}
+
+func (c *Collector) writeMemCache(key string, entry cacheEntry) {
+	c.memCacheMu.Lock()
+	defer c.memCacheMu.Unlock()
+	c.memCache[key] = entry
+}
+
+// cachePath maps a URL to a file under the cache directory, keyed by its
+// sha1 so arbitrary URLs become safe file names.
+func (c *Collector) cachePath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (c *Collector) readDiskCache(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.cachePath(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	statusLine, body, found := strings.Cut(string(data), "\n")
+	if !found {
+		return cacheEntry{}, false
+	}
+	status, err := strconv.Atoi(statusLine)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	return cacheEntry{status: status, body: []byte(body)}, true
+}
+
+func (c *Collector) writeDiskCache(key string, entry cacheEntry) {
+	if err := os.MkdirAll(c.cacheDir, 0o755); err != nil {
+		return
+	}
+	data := []byte(fmt.Sprintf("%d\n", entry.status))
+	data = append(data, entry.body...)
+	_ = os.WriteFile(c.cachePath(key), data, 0o644)
+}
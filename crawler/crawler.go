@@ -0,0 +1,347 @@
+/*
+Package crawler turns goelement into a small concurrent spider: point a
+Collector at one or more URLs, register selector-driven callbacks with
+OnHTML, and it fetches pages with a bounded worker pool, honoring
+per-host delays, domain filters and (optionally) robots.txt.
+
+Example:
+
+	c := crawler.New(
+		crawler.AllowedDomains("example.com"),
+		crawler.Concurrency(4),
+	)
+	c.OnHTML("a[href]", func(e *crawler.Element) {
+		e.Visit(e.Attr("href"))
+	})
+	c.Visit("https://example.com")
+	c.Wait()
+*/
+package crawler
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sjc1000/goelement"
+)
+
+// Request describes one page fetch.
+type Request struct {
+	URL   *url.URL
+	Depth int
+}
+
+// Response is what came back for a Request.
+type Response struct {
+	Request    *Request
+	StatusCode int
+	Body       []byte
+	Headers    http.Header
+}
+
+// htmlHandler pairs a compiled selector with the callback registered for it.
+type htmlHandler struct {
+	selector *goelement.Selector
+	fn       func(*Element)
+}
+
+// Collector crawls pages and dispatches them to registered handlers. Build
+// one with New and its functional options.
+type Collector struct {
+	AllowedDomains    []string
+	DisallowedDomains []string
+	URLFilterRegexp   *regexp.Regexp
+	MaxDepth          int
+	Concurrency       int
+	Delay             time.Duration
+	RandomDelay       time.Duration
+	UserAgent         string
+	Headers           http.Header
+	Cookies           []*http.Cookie
+	RespectRobotsTxt  bool
+
+	client   *http.Client
+	cacheDir string
+
+	htmlHandlers     []htmlHandler
+	responseHandlers []func(*Response)
+	errorHandlers    []func(*Response, error)
+	requestHandlers  []func(*Request)
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	visitedMu sync.Mutex
+	visited   map[string]bool
+
+	lastVisitMu sync.Mutex
+	lastVisit   map[string]time.Time
+
+	memCacheMu sync.Mutex
+	memCache   map[string]cacheEntry
+
+	robotsMu    sync.Mutex
+	robotsCache map[string]*robotsRules
+}
+
+// Option configures a Collector. Pass Options to New.
+type Option func(*Collector)
+
+// AllowedDomains restricts crawling to the given hostnames (and their
+// subdomains). With none set, every domain is allowed.
+func AllowedDomains(domains ...string) Option {
+	return func(c *Collector) { c.AllowedDomains = domains }
+}
+
+// DisallowedDomains excludes the given hostnames (and their subdomains)
+// from crawling.
+func DisallowedDomains(domains ...string) Option {
+	return func(c *Collector) { c.DisallowedDomains = domains }
+}
+
+// URLFilterRegexp only visits URLs whose string form matches re.
+func URLFilterRegexp(re *regexp.Regexp) Option {
+	return func(c *Collector) { c.URLFilterRegexp = re }
+}
+
+// MaxDepth caps how many link-hops from the initial Visit calls are
+// followed. Zero (the default) means unlimited.
+func MaxDepth(depth int) Option {
+	return func(c *Collector) { c.MaxDepth = depth }
+}
+
+// Concurrency bounds how many requests run at once. Default 1.
+func Concurrency(n int) Option {
+	return func(c *Collector) { c.Concurrency = n }
+}
+
+// Delay waits d between requests to the same host.
+func Delay(d time.Duration) Option {
+	return func(c *Collector) { c.Delay = d }
+}
+
+// RandomDelay adds up to d of extra random wait on top of Delay, so
+// requests to the same host aren't perfectly periodic.
+func RandomDelay(d time.Duration) Option {
+	return func(c *Collector) { c.RandomDelay = d }
+}
+
+// UserAgent sets the User-Agent header sent with every request.
+func UserAgent(ua string) Option {
+	return func(c *Collector) { c.UserAgent = ua }
+}
+
+// Headers sets extra headers sent with every request.
+func Headers(h http.Header) Option {
+	return func(c *Collector) { c.Headers = h }
+}
+
+// Cookies sends the given cookies with every request.
+func Cookies(cookies ...*http.Cookie) Option {
+	return func(c *Collector) { c.Cookies = cookies }
+}
+
+// RespectRobotsTxt makes the Collector fetch and obey each host's
+// robots.txt before visiting it.
+func RespectRobotsTxt(respect bool) Option {
+	return func(c *Collector) { c.RespectRobotsTxt = respect }
+}
+
+// Cache stores fetched responses under dir so a rerun doesn't re-fetch
+// them. Responses are also kept in memory for the life of the Collector.
+func Cache(dir string) Option {
+	return func(c *Collector) { c.cacheDir = dir }
+}
+
+// New builds a Collector, applying opts in order.
+func New(opts ...Option) *Collector {
+	c := &Collector{
+		Concurrency: 1,
+		client:      &http.Client{},
+		visited:     make(map[string]bool),
+		lastVisit:   make(map[string]time.Time),
+		memCache:    make(map[string]cacheEntry),
+		robotsCache: make(map[string]*robotsRules),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.Concurrency < 1 {
+		c.Concurrency = 1
+	}
+	c.sem = make(chan struct{}, c.Concurrency)
+	return c
+}
+
+// OnHTML registers fn to run, within the visiting goroutine, for every
+// element in a fetched page that matches selector.
+func (c *Collector) OnHTML(selector string, fn func(*Element)) {
+	c.htmlHandlers = append(c.htmlHandlers, htmlHandler{selector: goelement.MustCompileSelector(selector), fn: fn})
+}
+
+// OnResponse registers fn to run after every successful fetch.
+func (c *Collector) OnResponse(fn func(*Response)) {
+	c.responseHandlers = append(c.responseHandlers, fn)
+}
+
+// OnError registers fn to run whenever a fetch fails.
+func (c *Collector) OnError(fn func(*Response, error)) {
+	c.errorHandlers = append(c.errorHandlers, fn)
+}
+
+// OnRequest registers fn to run right before a request is sent.
+func (c *Collector) OnRequest(fn func(*Request)) {
+	c.requestHandlers = append(c.requestHandlers, fn)
+}
+
+// Visit queues rawURL for crawling at depth 0. It returns immediately;
+// call Wait to block until the crawl (including any links followed from
+// it) finishes.
+func (c *Collector) Visit(rawURL string) error {
+	return c.visitAtDepth(rawURL, 0)
+}
+
+// Wait blocks until every queued Visit, and every link followed from an
+// OnHTML handler, has finished.
+func (c *Collector) Wait() {
+	c.wg.Wait()
+}
+
+func (c *Collector) visitAtDepth(rawURL string, depth int) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("crawler: invalid URL %q: %w", rawURL, err)
+	}
+	c.wg.Add(1)
+	go c.doVisit(&Request{URL: u, Depth: depth})
+	return nil
+}
+
+func (c *Collector) doVisit(req *Request) {
+	defer c.wg.Done()
+
+	if !c.markVisited(canonicalizeURL(req.URL)) {
+		return
+	}
+	if !c.domainAllowed(req.URL.Hostname()) {
+		return
+	}
+	if c.URLFilterRegexp != nil && !c.URLFilterRegexp.MatchString(req.URL.String()) {
+		return
+	}
+	if c.MaxDepth > 0 && req.Depth > c.MaxDepth {
+		return
+	}
+	if c.RespectRobotsTxt && !c.robotsAllow(req.URL) {
+		return
+	}
+
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	c.waitForHostDelay(req.URL.Hostname())
+
+	for _, fn := range c.requestHandlers {
+		fn(req)
+	}
+
+	body, status, headers, err := c.fetch(req.URL)
+	resp := &Response{Request: req, StatusCode: status, Body: body, Headers: headers}
+	if err != nil {
+		for _, fn := range c.errorHandlers {
+			fn(resp, err)
+		}
+		return
+	}
+	for _, fn := range c.responseHandlers {
+		fn(resp)
+	}
+
+	root := goelement.ParseFromString(string(body))
+	if root == nil {
+		return
+	}
+	for _, h := range c.htmlHandlers {
+		nodes := root.QuerySelectorAll(h.selector)
+		if h.selector.Match(root) {
+			nodes = append([]*goelement.Node{root}, nodes...)
+		}
+		for _, n := range nodes {
+			h.fn(&Element{Node: n, URL: req.URL, collector: c, request: req})
+		}
+	}
+}
+
+// markVisited reports whether key hasn't been seen before, recording it
+// as seen either way.
+func (c *Collector) markVisited(key string) bool {
+	c.visitedMu.Lock()
+	defer c.visitedMu.Unlock()
+	if c.visited[key] {
+		return false
+	}
+	c.visited[key] = true
+	return true
+}
+
+// canonicalizeURL normalizes a URL for deduplication: lower-cased
+// scheme/host, no fragment.
+func canonicalizeURL(u *url.URL) string {
+	clean := *u
+	clean.Scheme = strings.ToLower(clean.Scheme)
+	clean.Host = strings.ToLower(clean.Host)
+	clean.Fragment = ""
+	return clean.String()
+}
+
+func (c *Collector) domainAllowed(host string) bool {
+	if len(c.AllowedDomains) > 0 && !matchesAnyDomain(host, c.AllowedDomains) {
+		return false
+	}
+	if matchesAnyDomain(host, c.DisallowedDomains) {
+		return false
+	}
+	return true
+}
+
+func matchesAnyDomain(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForHostDelay sleeps as needed so two requests to the same host are
+// at least Delay (plus up to RandomDelay) apart.
+func (c *Collector) waitForHostDelay(host string) {
+	if c.Delay == 0 && c.RandomDelay == 0 {
+		return
+	}
+	c.lastVisitMu.Lock()
+	last, ok := c.lastVisit[host]
+	wait := c.Delay
+	if c.RandomDelay > 0 {
+		wait += time.Duration(rand.Int63n(int64(c.RandomDelay)))
+	}
+	var sleep time.Duration
+	if ok {
+		if elapsed := time.Since(last); elapsed < wait {
+			sleep = wait - elapsed
+		}
+	}
+	c.lastVisit[host] = time.Now().Add(sleep)
+	c.lastVisitMu.Unlock()
+	if sleep > 0 {
+		time.Sleep(sleep)
+	}
+}
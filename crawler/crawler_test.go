@@ -0,0 +1,93 @@
+package crawler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Sjc1000/goelement/crawler"
+)
+
+func TestCrawlFollowsLinksWithinSite(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><h1>home</h1><a href="/page2">next</a></body></html>`))
+	})
+	mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><h1>page2</h1></body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var titles []string
+
+	c := crawler.New(crawler.Concurrency(2))
+	c.OnHTML("h1", func(e *crawler.Element) {
+		mu.Lock()
+		titles = append(titles, e.Text())
+		mu.Unlock()
+	})
+	c.OnHTML("a[href]", func(e *crawler.Element) {
+		if err := e.Visit(e.Attr("href")); err != nil {
+			t.Errorf("Visit: %v", err)
+		}
+	})
+
+	if err := c.Visit(server.URL); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	c.Wait()
+
+	sort.Strings(titles)
+	if len(titles) != 2 || titles[0] != "home" || titles[1] != "page2" {
+		t.Fatalf("got titles %v, want [home page2]", titles)
+	}
+}
+
+func TestCrawlRespectsAllowedDomains(t *testing.T) {
+	var visited int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		visited++
+		w.Write([]byte(`<html><body>hi</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := crawler.New(crawler.AllowedDomains("example.invalid"))
+	if err := c.Visit(server.URL); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	c.Wait()
+
+	if visited != 0 {
+		t.Fatalf("request reached the server despite AllowedDomains excluding its host")
+	}
+}
+
+func TestCrawlDoesNotRevisitSameURL(t *testing.T) {
+	var hits int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`<html><body>hi</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := crawler.New()
+	if err := c.Visit(server.URL + "/page"); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	if err := c.Visit(server.URL + "/page"); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	c.Wait()
+
+	if hits != 1 {
+		t.Fatalf("server was hit %d times, want exactly 1 (Visit on an already-visited URL should be a no-op)", hits)
+	}
+}
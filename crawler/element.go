@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Sjc1000/goelement"
+	"golang.org/x/net/html"
+)
+
+// Element wraps a matched *goelement.Node with the page it was found on,
+// letting handlers resolve relative links and queue further visits.
+type Element struct {
+	Node *goelement.Node
+	URL  *url.URL
+
+	collector *Collector
+	request   *Request
+}
+
+// Attr returns the value of an attribute, or "" if it isn't set.
+func (e *Element) Attr(key string) string {
+	attr, ok := e.Node.Attributes[key]
+	if !ok {
+		return ""
+	}
+	return attr.Val
+}
+
+// Text returns the concatenated, whitespace-collapsed text of the
+// element and its descendants.
+func (e *Element) Text() string {
+	var b strings.Builder
+	e.Node.Walk(func(n *goelement.Node) bool {
+		if n.Type == html.TextToken {
+			b.WriteString(n.Data)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// AbsoluteURL resolves the value of attr against the page's URL, so a
+// handler can follow a relative href or src.
+func (e *Element) AbsoluteURL(attr string) string {
+	raw := e.Attr(attr)
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return e.URL.ResolveReference(ref).String()
+}
+
+// ForEach runs fn for every descendant of the element matching selector.
+func (e *Element) ForEach(selector string, fn func(*Element)) {
+	sel := goelement.MustCompileSelector(selector)
+	for _, n := range e.Node.QuerySelectorAll(sel) {
+		fn(&Element{Node: n, URL: e.URL, collector: e.collector, request: e.request})
+	}
+}
+
+// Visit resolves href (typically the value of an attribute, e.g.
+// e.Attr("href")) against the element's page and queues it for crawling
+// one depth deeper than the page it was found on.
+func (e *Element) Visit(href string) error {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return &url.Error{Op: "parse", URL: href, Err: err}
+	}
+	abs := e.URL.ResolveReference(ref).String()
+	return e.collector.visitAtDepth(abs, e.request.Depth+1)
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// robotsRules is the small part of robots.txt this package understands:
+// the Disallow prefixes that apply to every user agent.
+type robotsRules struct {
+	disallow []string
+}
+
+func (r *robotsRules) allows(path string) bool {
+	for _, prefix := range r.disallow {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// robotsAllow reports whether u's path is allowed by its host's
+// robots.txt, fetching and caching the rules on first use. Hosts whose
+// robots.txt can't be fetched are treated as allowing everything.
+func (c *Collector) robotsAllow(u *url.URL) bool {
+	rules := c.robotsRulesFor(u)
+	if rules == nil {
+		return true
+	}
+	return rules.allows(u.Path)
+}
+
+func (c *Collector) robotsRulesFor(u *url.URL) *robotsRules {
+	host := u.Hostname()
+
+	c.robotsMu.Lock()
+	if rules, ok := c.robotsCache[host]; ok {
+		c.robotsMu.Unlock()
+		return rules
+	}
+	c.robotsMu.Unlock()
+
+	rules := fetchRobotsRules(u)
+
+	c.robotsMu.Lock()
+	c.robotsCache[host] = rules
+	c.robotsMu.Unlock()
+	return rules
+}
+
+func fetchRobotsRules(pageURL *url.URL) *robotsRules {
+	robotsURL := &url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/robots.txt"}
+	resp, err := http.Get(robotsURL.String())
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil
+	}
+	return parseRobotsRules(string(body))
+}
+
+// parseRobotsRules reads the Disallow lines that apply to "User-agent: *"
+// groups, which covers the common case without implementing the entire
+// robots.txt grammar (group precedence, Allow overrides, wildcards).
+func parseRobotsRules(body string) *robotsRules {
+	rules := &robotsRules{}
+	appliesToUs := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+		switch field {
+		case "user-agent":
+			appliesToUs = value == "*"
+		case "disallow":
+			if appliesToUs {
+				rules.disallow = append(rules.disallow, value)
+			}
+		}
+	}
+	return rules
+}
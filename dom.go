@@ -0,0 +1,223 @@
+package goelement
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// AppendChild adds child as the last child of node, detaching it from its
+// previous parent first.
+func (node *Node) AppendChild(child *Node) {
+	child.detach()
+	node.appendChildFast(child)
+}
+
+// PrependChild adds child as the first child of node, detaching it from
+// its previous parent first.
+func (node *Node) PrependChild(child *Node) {
+	child.detach()
+	child.Parent = node
+	node.Children = append([]*Node{child}, node.Children...)
+	child.PrevSibling = nil
+	child.NextSibling = node.FirstChild
+	if node.FirstChild != nil {
+		node.FirstChild.PrevSibling = child
+	} else {
+		node.LastChild = child
+	}
+	node.FirstChild = child
+}
+
+// InsertBefore inserts newNode as node's previous sibling.
+func (node *Node) InsertBefore(newNode *Node) {
+	node.insertSibling(newNode, true)
+}
+
+// InsertAfter inserts newNode as node's next sibling.
+func (node *Node) InsertAfter(newNode *Node) {
+	node.insertSibling(newNode, false)
+}
+
+// insertSibling inserts newNode next to node in node's parent, either
+// immediately before or immediately after it.
+func (node *Node) insertSibling(newNode *Node, before bool) {
+	if node.Parent == nil {
+		return
+	}
+	newNode.detach()
+	parent := node.Parent
+	newNode.Parent = parent
+
+	index := node.indexInParent()
+	offset := 1
+	if before {
+		offset = 0
+	}
+	children := make([]*Node, 0, len(parent.Children)+1)
+	children = append(children, parent.Children[:index+offset]...)
+	children = append(children, newNode)
+	children = append(children, parent.Children[index+offset:]...)
+	parent.Children = children
+
+	if before {
+		newNode.PrevSibling = node.PrevSibling
+		newNode.NextSibling = node
+		if node.PrevSibling != nil {
+			node.PrevSibling.NextSibling = newNode
+		} else {
+			parent.FirstChild = newNode
+		}
+		node.PrevSibling = newNode
+	} else {
+		newNode.NextSibling = node.NextSibling
+		newNode.PrevSibling = node
+		if node.NextSibling != nil {
+			node.NextSibling.PrevSibling = newNode
+		} else {
+			parent.LastChild = newNode
+		}
+		node.NextSibling = newNode
+	}
+}
+
+// Remove detaches node from its parent.
+func (node *Node) Remove() {
+	node.detach()
+}
+
+// ReplaceWith replaces node with newNode in node's parent.
+func (node *Node) ReplaceWith(newNode *Node) {
+	if node.Parent == nil {
+		return
+	}
+	newNode.detach()
+	parent := node.Parent
+	index := node.indexInParent()
+
+	newNode.Parent = parent
+	newNode.PrevSibling = node.PrevSibling
+	newNode.NextSibling = node.NextSibling
+	if node.PrevSibling != nil {
+		node.PrevSibling.NextSibling = newNode
+	} else {
+		parent.FirstChild = newNode
+	}
+	if node.NextSibling != nil {
+		node.NextSibling.PrevSibling = newNode
+	} else {
+		parent.LastChild = newNode
+	}
+	parent.Children[index] = newNode
+
+	node.Parent = nil
+	node.PrevSibling = nil
+	node.NextSibling = nil
+}
+
+// detach removes node from its parent's Children and unlinks it from its
+// siblings, if it has a parent.
+func (node *Node) detach() {
+	parent := node.Parent
+	if parent == nil {
+		return
+	}
+	index := node.indexInParent()
+	parent.Children = append(parent.Children[:index], parent.Children[index+1:]...)
+
+	if node.PrevSibling != nil {
+		node.PrevSibling.NextSibling = node.NextSibling
+	} else {
+		parent.FirstChild = node.NextSibling
+	}
+	if node.NextSibling != nil {
+		node.NextSibling.PrevSibling = node.PrevSibling
+	} else {
+		parent.LastChild = node.PrevSibling
+	}
+
+	node.Parent = nil
+	node.PrevSibling = nil
+	node.NextSibling = nil
+}
+
+// indexInParent returns node's position among its parent's children, or
+// -1 if it has no parent or isn't found.
+func (node *Node) indexInParent() int {
+	if node.Parent == nil {
+		return -1
+	}
+	for i, child := range node.Parent.Children {
+		if child == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetAttr sets an attribute to val, adding it if it doesn't already
+// exist.
+func (node *Node) SetAttr(key, val string) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == key {
+			node.Attr[i].Val = val
+			node.syncAttributes()
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: val})
+	node.syncAttributes()
+}
+
+// RemoveAttr removes an attribute, if present.
+func (node *Node) RemoveAttr(key string) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == key {
+			node.Attr = append(node.Attr[:i], node.Attr[i+1:]...)
+			node.syncAttributes()
+			return
+		}
+	}
+}
+
+// AddClass adds class to the node's class attribute, if it isn't already
+// present.
+func (node *Node) AddClass(class string) {
+	if node.HasClass(class) {
+		return
+	}
+	var existing string
+	if attr, ok := node.Attributes["class"]; ok {
+		existing = attr.Val
+	}
+	classes := strings.Fields(existing)
+	classes = append(classes, class)
+	node.SetAttr("class", strings.Join(classes, " "))
+}
+
+// RemoveClass removes class from the node's class attribute, if present.
+func (node *Node) RemoveClass(class string) {
+	attr, ok := node.Attributes["class"]
+	if !ok {
+		return
+	}
+	var kept []string
+	for _, token := range strings.Fields(attr.Val) {
+		if token != class {
+			kept = append(kept, token)
+		}
+	}
+	if len(kept) == 0 {
+		node.RemoveAttr("class")
+		return
+	}
+	node.SetAttr("class", strings.Join(kept, " "))
+}
+
+// SetText replaces node's children with a single text node containing
+// text.
+func (node *Node) SetText(text string) {
+	for node.FirstChild != nil {
+		node.FirstChild.detach()
+	}
+	node.AppendChild(newNode(html.Token{Type: html.TextToken, Data: text}, nil))
+}
@@ -0,0 +1,174 @@
+package goelement_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+)
+
+// assertChildrenConsistent checks that Children and the
+// FirstChild/LastChild/NextSibling/PrevSibling chain agree on node's
+// direct children, in both directions.
+func assertChildrenConsistent(t *testing.T, node *goelement.Node) {
+	t.Helper()
+
+	var viaSiblings []*goelement.Node
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		viaSiblings = append(viaSiblings, c)
+	}
+	if len(viaSiblings) != len(node.Children) {
+		t.Fatalf("FirstChild/NextSibling chain has %d nodes, Children has %d", len(viaSiblings), len(node.Children))
+	}
+	for i, c := range viaSiblings {
+		if c != node.Children[i] {
+			t.Fatalf("child %d differs between sibling chain and Children slice", i)
+		}
+		if c.Parent != node {
+			t.Fatalf("child %d's Parent isn't node", i)
+		}
+	}
+	if len(viaSiblings) > 0 {
+		if node.FirstChild != viaSiblings[0] {
+			t.Fatalf("FirstChild doesn't match first entry")
+		}
+		if node.LastChild != viaSiblings[len(viaSiblings)-1] {
+			t.Fatalf("LastChild doesn't match last entry")
+		}
+		if viaSiblings[0].PrevSibling != nil {
+			t.Fatalf("first child has a non-nil PrevSibling")
+		}
+		if viaSiblings[len(viaSiblings)-1].NextSibling != nil {
+			t.Fatalf("last child has a non-nil NextSibling")
+		}
+	} else if node.FirstChild != nil || node.LastChild != nil {
+		t.Fatalf("childless node has a non-nil FirstChild/LastChild")
+	}
+}
+
+func TestAppendPrependChild(t *testing.T) {
+	root := goelement.ParseFromString(`<ul><li>a</li><li>b</li></ul>`)
+	ul := root.FindTag("ul")
+
+	appended := goelement.ParseFromString(`<li>c</li>`)
+	ul.AppendChild(appended)
+	assertChildrenConsistent(t, ul)
+	if ul.LastChild != appended {
+		t.Fatalf("AppendChild didn't become the last child")
+	}
+
+	prepended := goelement.ParseFromString(`<li>z</li>`)
+	ul.PrependChild(prepended)
+	assertChildrenConsistent(t, ul)
+	if ul.FirstChild != prepended {
+		t.Fatalf("PrependChild didn't become the first child")
+	}
+	if len(ul.Children) != 4 {
+		t.Fatalf("expected 4 children, got %d", len(ul.Children))
+	}
+}
+
+func TestInsertBeforeAfter(t *testing.T) {
+	root := goelement.ParseFromString(`<ul><li>a</li><li>c</li></ul>`)
+	ul := root.FindTag("ul")
+	items := ul.QueryAll("li")
+	first, last := items[0], items[1]
+
+	middle := goelement.ParseFromString(`<li>b</li>`)
+	last.InsertBefore(middle)
+	assertChildrenConsistent(t, ul)
+
+	var texts []string
+	for c := ul.FirstChild; c != nil; c = c.NextSibling {
+		texts = append(texts, c.Text())
+	}
+	if len(texts) != 3 || texts[0] != "a" || texts[1] != "b" || texts[2] != "c" {
+		t.Fatalf("got order %v, want [a b c]", texts)
+	}
+
+	after := goelement.ParseFromString(`<li>d</li>`)
+	first.InsertAfter(after)
+	assertChildrenConsistent(t, ul)
+	if ul.FirstChild.NextSibling != after {
+		t.Fatalf("InsertAfter didn't land right after the anchor")
+	}
+}
+
+func TestRemoveAndReplaceWith(t *testing.T) {
+	root := goelement.ParseFromString(`<ul><li>a</li><li>b</li><li>c</li></ul>`)
+	ul := root.FindTag("ul")
+	items := ul.QueryAll("li")
+
+	items[1].Remove()
+	assertChildrenConsistent(t, ul)
+	if len(ul.Children) != 2 {
+		t.Fatalf("expected 2 children after Remove, got %d", len(ul.Children))
+	}
+	if items[1].Parent != nil {
+		t.Fatalf("removed node still has a Parent")
+	}
+
+	replacement := goelement.ParseFromString(`<li>z</li>`)
+	ul.FirstChild.ReplaceWith(replacement)
+	assertChildrenConsistent(t, ul)
+	if ul.FirstChild != replacement {
+		t.Fatalf("ReplaceWith didn't take the old node's place")
+	}
+}
+
+func TestSetTextDetachesOldChildren(t *testing.T) {
+	root := goelement.ParseFromString(`<body><div><p>one</p><p>two</p></div><p>three</p></body>`)
+	div := root.FindTag("div")
+
+	div.SetText("replaced")
+	assertChildrenConsistent(t, div)
+
+	if got := div.Text(); got != "replaced" {
+		t.Fatalf("div.Text() = %q, want %q", got, "replaced")
+	}
+	if got := len(root.QueryAll("p")); got != 1 {
+		t.Fatalf("QueryAll(p) found %d nodes after SetText, want 1 (only the untouched sibling)", got)
+	}
+}
+
+func TestAttrAndClassMutation(t *testing.T) {
+	root := goelement.ParseFromString(`<div class="a b"></div>`)
+	div := root.FindTag("div")
+
+	div.SetAttr("title", "hi")
+	if div.Attributes["title"].Val != "hi" {
+		t.Fatalf("SetAttr didn't set title")
+	}
+	div.RemoveAttr("title")
+	if _, ok := div.Attributes["title"]; ok {
+		t.Fatalf("RemoveAttr left title behind")
+	}
+
+	div.AddClass("c")
+	if !div.HasClass("c") || !div.HasClass("a") {
+		t.Fatalf("AddClass should keep existing classes and add the new one")
+	}
+	div.RemoveClass("a")
+	if div.HasClass("a") {
+		t.Fatalf("RemoveClass didn't remove a")
+	}
+	if !div.HasClass("b") || !div.HasClass("c") {
+		t.Fatalf("RemoveClass shouldn't touch other classes")
+	}
+}
+
+func TestDoctypeSurvivesRoundTrip(t *testing.T) {
+	root := goelement.ParseFromString("<!DOCTYPE html>\n<html><body>hi</body></html>")
+
+	got := root.HTML()
+	if !strings.Contains(got, "<!DOCTYPE html>") {
+		t.Fatalf("HTML() = %q, want the leading doctype preserved", got)
+	}
+	if !strings.Contains(got, "<body>hi</body>") {
+		t.Fatalf("HTML() = %q, want the document content preserved too", got)
+	}
+
+	if body := root.FindTag("body"); body == nil || body.Text() != "hi" {
+		t.Fatalf("FindTag(\"body\") should still find the element through the synthetic container")
+	}
+}
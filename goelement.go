@@ -48,11 +48,35 @@ type NodePath struct {
 }
 
 // Node is a simple structure containing information about each HTML node.
+//
+// Children still holds every child in document order for backward
+// compatibility, while FirstChild/LastChild/NextSibling/PrevSibling mirror
+// the shape of x/net/html.Node so sibling-aware lookups don't need an O(n)
+// scan of Children.
 type Node struct {
 	html.Token
-	Parent     *Node
-	Children   []*Node
-	Attributes map[string]*html.Attribute
+	Parent      *Node
+	Children    []*Node
+	FirstChild  *Node
+	LastChild   *Node
+	NextSibling *Node
+	PrevSibling *Node
+	Attributes  map[string]*html.Attribute
+}
+
+// Walk visits node and its descendants in pre-order (node, then each
+// child's subtree left to right), stopping as soon as fn returns false.
+// It reports whether traversal completed without being stopped early.
+func (node *Node) Walk(fn func(*Node) bool) bool {
+	if !fn(node) {
+		return false
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if !child.Walk(fn) {
+			return false
+		}
+	}
+	return true
 }
 
 // FindTagReverse finds a tag but goes through parents instead of children.
@@ -68,16 +92,15 @@ func (node *Node) FindTagReverse(tag string) *Node {
 
 // FindTag finds and returns a child if it has the tagname specified.
 func (node *Node) FindTag(tag string) *Node {
-	if node.Data == tag {
-		return node
-	}
-	for _, child := range node.Children {
-		result := child.FindTag(tag)
-		if result != nil {
-			return result
+	var found *Node
+	node.Walk(func(n *Node) bool {
+		if n.isElement() && n.Data == tag {
+			found = n
+			return false
 		}
-	}
-	return nil
+		return true
+	})
+	return found
 }
 
 // FindPath finds a single element with a specific path.
@@ -109,16 +132,17 @@ func (node *Node) findAll(nodePath *NodePath, nodes *[]*Node) {
 	path := nodePath.Path
 	class := nodePath.Class
 	ID := nodePath.ID
-	if node.MatchesPath(path) == true && node.HasClass(class) && node.HasID(ID) {
-		*nodes = append(*nodes, node)
-	}
-
-	for _, child := range node.Children {
-		child.findAll(nodePath, nodes)
-	}
+	node.Walk(func(n *Node) bool {
+		if n.isElement() && n.MatchesPath(path) == true && n.HasClass(class) && n.HasID(ID) {
+			*nodes = append(*nodes, n)
+		}
+		return true
+	})
 }
 
-// HasClass checks if a Node has a class.
+// HasClass checks if a Node has a class. Since an element's class
+// attribute can hold several space-separated classes, this checks for
+// class as one of those tokens rather than an exact match.
 func (node *Node) HasClass(class string) bool {
 	if class == "" {
 		return true
@@ -127,7 +151,12 @@ func (node *Node) HasClass(class string) bool {
 	if !ok {
 		return false
 	}
-	return check.Val == class
+	for _, token := range strings.Fields(check.Val) {
+		if token == class {
+			return true
+		}
+	}
+	return false
 }
 
 // HasID checks if a Node has an ID.
@@ -152,20 +181,15 @@ func (node *Node) Path() string {
 // Do you have too many grandchildren? Can't remember their names and attributes? Look no further! FlattenChildren() has what you're looking for! Flatten any sized family tree in mere moments! Yes you heard correctly! Any size, In just moments! Call 1800 FLATTEN now for a free trial!
 func (node *Node) FlattenChildren() []*Node {
 	var nodes []*Node
-	for _, child := range node.Children {
-		child.getChildTree(&nodes)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		child.Walk(func(n *Node) bool {
+			nodes = append(nodes, n)
+			return true
+		})
 	}
 	return nodes
 }
 
-// getChildTree does most of the heavy lifting for FlattenChildren()
-func (node *Node) getChildTree(nodes *[]*Node) {
-	*nodes = append(*nodes, node)
-	for _, child := range node.Children {
-		child.getChildTree(nodes)
-	}
-}
-
 // createPath does the heavy lifting for Path()
 func (node *Node) createPath(path string) string {
 	path = fmt.Sprintf("/%s%s", node.Data, path)
@@ -175,16 +199,22 @@ func (node *Node) createPath(path string) string {
 	return node.Parent.createPath(path)
 }
 
-// PrintStructure prints the structure of the Nodes children.
+// PrintStructure prints the structure of the Nodes children, skipping
+// over text/comment/doctype children since they aren't elements.
 func (node *Node) PrintStructure(indent int, character string) {
 	for i := 0; i < indent; i++ {
 		fmt.Print(character)
 	}
 	fmt.Println(node.Data)
+	printed := false
 	for _, child := range node.Children {
+		if !child.isElement() {
+			continue
+		}
 		child.PrintStructure(indent+1, character)
+		printed = true
 	}
-	if len(node.Children) == 0 {
+	if !printed {
 		return
 	}
 	for i := 0; i < indent; i++ {
@@ -228,46 +258,111 @@ func (node *Node) MatchesPath(path string) bool {
 
 // NewNode creates a new Node instance.
 func newNode(token html.Token, parent *Node) *Node {
-	attrs := make(map[string]*html.Attribute)
-	for _, attr := range token.Attr {
-		attrs[attr.Key] = &attr
-	}
-	node := &Node{Token: token, Parent: parent, Attributes: attrs}
+	node := &Node{Token: token, Parent: parent}
+	node.syncAttributes()
 	return node
 }
 
-// dive through the HTML returning the root node.
+// syncAttributes rebuilds the Attributes lookup map from the current
+// Attr slice. It must be called after anything changes node.Attr so the
+// two stay consistent.
+func (node *Node) syncAttributes() {
+	node.Attributes = make(map[string]*html.Attribute, len(node.Attr))
+	for i := range node.Attr {
+		node.Attributes[node.Attr[i].Key] = &node.Attr[i]
+	}
+}
+
+// appendChildFast appends child to parent's Children and links it in as
+// the new last sibling. Unlike AppendChild it assumes child is freshly
+// created and doesn't need detaching from an old parent, which keeps the
+// tokenizer loop in dive() cheap.
+func (parent *Node) appendChildFast(child *Node) {
+	child.Parent = parent
+	parent.Children = append(parent.Children, child)
+	child.PrevSibling = parent.LastChild
+	if parent.LastChild != nil {
+		parent.LastChild.NextSibling = child
+	} else {
+		parent.FirstChild = child
+	}
+	parent.LastChild = child
+}
+
+// containerTokenType marks the synthetic container dive() builds to hold
+// sibling top-level nodes (a leading <!DOCTYPE html>, stray text/comments,
+// and the root element) when there's more than one of them. It isn't a
+// real html.TokenType the tokenizer ever produces.
+const containerTokenType html.TokenType = html.TokenType(255)
+
+// dive through the HTML returning the root node. Tokens seen before the
+// root element opens (most commonly a leading <!DOCTYPE html>) are kept
+// as leading siblings instead of being dropped, so Render/HTML round-trip
+// what was parsed.
 func dive(tokenizer *html.Tokenizer) *Node {
 	var parent *Node
-	var root *Node
+	var top []*Node
 
 	for {
 		tokenType := tokenizer.Next()
 		if tokenType == html.ErrorToken {
-			return root
+			return wrapTopLevel(top)
 		}
 		current := tokenizer.Token()
 		switch tokenType {
 		case html.StartTagToken:
 			node := newNode(current, parent)
-			if root == nil {
-				root = node
+			if parent == nil {
+				top = append(top, node)
 			} else {
-				parent.Children = append(parent.Children, node)
+				parent.appendChildFast(node)
 			}
 			parent = node
 		case html.SelfClosingTagToken:
 			node := newNode(current, parent)
-			parent.Children = append(parent.Children, node)
+			if parent == nil {
+				top = append(top, node)
+			} else {
+				parent.appendChildFast(node)
+			}
+		case html.TextToken, html.CommentToken, html.DoctypeToken:
+			node := newNode(current, parent)
+			if parent == nil {
+				top = append(top, node)
+			} else {
+				parent.appendChildFast(node)
+			}
 		case html.EndTagToken:
-			tag := parent.FindTagReverse(current.Data)
-			if tag != nil {
-				parent = tag.Parent
+			if parent != nil {
+				tag := parent.FindTagReverse(current.Data)
+				if tag != nil {
+					parent = tag.Parent
+				}
 			}
 		}
 	}
 }
 
+// wrapTopLevel turns the slice of top-level siblings dive() collected into
+// the single *Node ParseFromString/ParseFromURL return. With just one
+// sibling (the common case: a document with no leading doctype/text) it's
+// returned as-is, unchanged from before. With more than one, they're
+// wrapped in a synthetic container node so none of them get dropped.
+func wrapTopLevel(top []*Node) *Node {
+	if len(top) == 0 {
+		return nil
+	}
+	if len(top) == 1 {
+		return top[0]
+	}
+	container := newNode(html.Token{Type: containerTokenType}, nil)
+	for _, node := range top {
+		node.Parent = container
+		container.appendChildFast(node)
+	}
+	return container
+}
+
 // ParseFromURL parses HTML from a website.
 func ParseFromURL(url string) (*Node, error) {
 	response, err := http.Get(url)
@@ -1,32 +1,64 @@
 package goelement_test
 
-import "github.com/Sjc1000/goelement"
+import (
+	"fmt"
+
+	"github.com/Sjc1000/goelement"
+)
+
+const exampleHTML = `
+<html>
+	<body>
+		<div>
+			<h1 class="element">Testing</h1>
+			<a href="/a">link a</a>
+		</div>
+		<h1 class="outer">Wooo!</h1>
+		<div>
+			<a href="/b">link b</a>
+		</div>
+	</body>
+</html>
+`
 
 func ExampleNode_FindTagReverse() {
-	parent := child.FindTagReverse("a")
-	fmt.Println(parent.Data)
+	root := goelement.ParseFromString(exampleHTML)
+	a := root.FindTag("a")
+	div := a.FindTagReverse("div")
+	fmt.Println(div.Data)
+	// Output: div
 }
 
 func ExampleNode_FindTag() {
-	child := parent.FindTag("a")
-	fmt.Println(parent.Data)
+	root := goelement.ParseFromString(exampleHTML)
+	a := root.FindTag("a")
+	fmt.Println(a.Data)
+	// Output: a
 }
 
 func ExampleNode_FindPath() {
-	path := goelement.NodePath{Path: "div/h1"}
-	element := root.FindPath(path)
+	root := goelement.ParseFromString(exampleHTML)
+	element := root.FindPath(&goelement.NodePath{Path: "div/h1"})
 	fmt.Println(element.Data)
+	// Output: h1
 }
 
 func ExampleNode_FindPathAll() {
-	path := goelement.NodePath{Path: "div/a"}
-	elements := root.FindPathAll(path)
+	root := goelement.ParseFromString(exampleHTML)
+	elements := root.FindPathAll(&goelement.NodePath{Path: "div/a"})
 	for _, element := range elements {
 		fmt.Println(element.Data)
 	}
+	// Output:
+	// a
+	// a
 }
 
 func ExampleNode_PrintStructure() {
-	root := goelement.ParseFromString(html_data)
+	root := goelement.ParseFromString(`<div><h1>hi</h1></div>`)
 	root.PrintStructure(0, "  ")
+	// Output:
+	// div
+	//   h1
+	// div
 }
@@ -0,0 +1,64 @@
+package goelement
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"io"
+)
+
+// Render writes node and its descendants out as HTML5 using
+// golang.org/x/net/html's renderer.
+func (node *Node) Render(w io.Writer) error {
+	return html.Render(w, node.toHTMLNode())
+}
+
+// HTML serializes node and its descendants and returns the result as a
+// string.
+func (node *Node) HTML() string {
+	var buf bytes.Buffer
+	node.Render(&buf) // a bytes.Buffer never returns a write error
+	return buf.String()
+}
+
+// toHTMLNode copies node's subtree into the *html.Node tree that
+// x/net/html's Render expects: Node's sibling links mirror html.Node's,
+// but Node is a different type, so the renderer can't walk it directly.
+func (node *Node) toHTMLNode() *html.Node {
+	n := &html.Node{
+		Type:     tokenTypeToNodeType(node.Type),
+		DataAtom: node.DataAtom,
+		Data:     node.Data,
+		Attr:     node.Attr,
+	}
+	var prev *html.Node
+	for _, child := range node.Children {
+		c := child.toHTMLNode()
+		c.Parent = n
+		if prev == nil {
+			n.FirstChild = c
+		} else {
+			prev.NextSibling = c
+			c.PrevSibling = prev
+		}
+		prev = c
+	}
+	n.LastChild = prev
+	return n
+}
+
+// tokenTypeToNodeType maps the html.TokenType stored on Node (from the
+// tokenizer) to the html.NodeType the renderer works with.
+func tokenTypeToNodeType(t html.TokenType) html.NodeType {
+	switch t {
+	case html.TextToken:
+		return html.TextNode
+	case html.CommentToken:
+		return html.CommentNode
+	case html.DoctypeToken:
+		return html.DoctypeNode
+	case containerTokenType:
+		return html.DocumentNode
+	default:
+		return html.ElementNode
+	}
+}
@@ -0,0 +1,626 @@
+package goelement
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
+)
+
+// Selector is a compiled CSS selector. Use MustCompileSelector or
+// CompileSelector to build one, then Match/Node.Query/Node.QueryAll to
+// apply it against a tree.
+type Selector struct {
+	list []complexSelector
+}
+
+// complexSelector is a chain of compound selectors joined by combinators,
+// e.g. "div.foo > span + a" becomes compounds [div.foo, span, a] with
+// combinators ['>', '+'] sitting between them.
+type complexSelector struct {
+	compounds   []compound
+	combinators []byte
+}
+
+// compound is a sequence of simple selectors that must all match the same
+// node, e.g. "div.foo#bar[title]".
+type compound struct {
+	matchers []matcher
+}
+
+// matcher is a single simple selector (type, class, id, attribute or
+// pseudo-class) able to test one Node.
+type matcher interface {
+	match(node *Node) bool
+}
+
+// CompileSelector parses a CSS selector string into a reusable Selector.
+func CompileSelector(selector string) (*Selector, error) {
+	p := &selectorParser{input: selector}
+	list, err := p.parseSelectorList()
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{list: list}, nil
+}
+
+// MustCompileSelector is like CompileSelector but panics if the selector is
+// invalid. Intended for selectors known at compile time.
+func MustCompileSelector(selector string) *Selector {
+	sel, err := CompileSelector(selector)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// Match reports whether node satisfies the selector.
+func (s *Selector) Match(node *Node) bool {
+	for _, cs := range s.list {
+		if cs.matches(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsSiblingContext reports whether s can only be evaluated correctly
+// with sibling information available: a "+"/"~" combinator, or a
+// structural pseudo-class like :first-child/:last-child/:nth-child.
+func (s *Selector) needsSiblingContext() bool {
+	for _, cs := range s.list {
+		for _, comb := range cs.combinators {
+			if comb == '+' || comb == '~' {
+				return true
+			}
+		}
+		for _, c := range cs.compounds {
+			for _, m := range c.matchers {
+				switch m := m.(type) {
+				case firstChildMatcher, lastChildMatcher, nthChildMatcher:
+					return true
+				case notMatcher:
+					if m.inner.needsSiblingContext() {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Query finds the first descendant (in document order) of node that
+// matches selector.
+func (node *Node) Query(selector string) *Node {
+	sel := MustCompileSelector(selector)
+	return node.QuerySelector(sel)
+}
+
+// QueryAll finds every descendant of node that matches selector, in
+// document order.
+func (node *Node) QueryAll(selector string) []*Node {
+	sel := MustCompileSelector(selector)
+	return node.QuerySelectorAll(sel)
+}
+
+// QuerySelector finds the first descendant matching an already compiled
+// Selector. Prefer this over Query when running the same selector
+// repeatedly.
+func (node *Node) QuerySelector(sel *Selector) *Node {
+	var found *Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		child.Walk(func(n *Node) bool {
+			if sel.Match(n) {
+				found = n
+				return false
+			}
+			return true
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// QuerySelectorAll finds every descendant matching an already compiled
+// Selector, in document order.
+func (node *Node) QuerySelectorAll(sel *Selector) []*Node {
+	var nodes []*Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		child.Walk(func(n *Node) bool {
+			if sel.Match(n) {
+				nodes = append(nodes, n)
+			}
+			return true
+		})
+	}
+	return nodes
+}
+
+// matches reports whether node satisfies the rightmost compound, then
+// walks the combinator chain leftwards to check ancestry/sibling
+// constraints.
+func (cs complexSelector) matches(node *Node) bool {
+	last := len(cs.compounds) - 1
+	if !cs.compounds[last].matches(node) {
+		return false
+	}
+
+	current := node
+	for i := last - 1; i >= 0; i-- {
+		switch cs.combinators[i] {
+		case ' ':
+			ancestor := current.Parent
+			found := false
+			for ancestor != nil {
+				if cs.compounds[i].matches(ancestor) {
+					found = true
+					break
+				}
+				ancestor = ancestor.Parent
+			}
+			if !found {
+				return false
+			}
+			current = ancestor
+		case '>':
+			if current.Parent == nil || !cs.compounds[i].matches(current.Parent) {
+				return false
+			}
+			current = current.Parent
+		case '+':
+			sibling := current.prevElementSibling()
+			if sibling == nil || !cs.compounds[i].matches(sibling) {
+				return false
+			}
+			current = sibling
+		case '~':
+			sibling := current.prevElementSibling()
+			found := false
+			for sibling != nil {
+				if cs.compounds[i].matches(sibling) {
+					found = true
+					break
+				}
+				sibling = sibling.prevElementSibling()
+			}
+			if !found {
+				return false
+			}
+			current = sibling
+		}
+	}
+	return true
+}
+
+func (c compound) matches(node *Node) bool {
+	if !node.isElement() {
+		return false
+	}
+	for _, m := range c.matchers {
+		if !m.match(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// isElement reports whether node is a tag, as opposed to a text, comment
+// or doctype node.
+func (node *Node) isElement() bool {
+	return node.Type == html.StartTagToken || node.Type == html.SelfClosingTagToken
+}
+
+// prevElementSibling returns the element immediately before node among its
+// parent's children, skipping over any text/comment nodes, or nil if
+// there isn't one.
+func (node *Node) prevElementSibling() *Node {
+	for s := node.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.isElement() {
+			return s
+		}
+	}
+	return nil
+}
+
+// typeMatcher matches the tag name, or anything when the tag is "*".
+type typeMatcher struct{ tag string }
+
+func (m typeMatcher) match(node *Node) bool {
+	if !node.isElement() {
+		return false
+	}
+	return m.tag == "*" || node.Data == m.tag
+}
+
+// idMatcher matches the "id" attribute exactly.
+type idMatcher struct{ id string }
+
+func (m idMatcher) match(node *Node) bool { return node.HasID(m.id) }
+
+// classMatcher matches one class in a space-separated "class" attribute.
+type classMatcher struct{ class string }
+
+func (m classMatcher) match(node *Node) bool { return node.HasClass(m.class) }
+
+// attrMatcher matches "[attr]", "[attr=val]" and friends.
+type attrMatcher struct {
+	key string
+	op  string // "", "=", "~=", "|=", "^=", "$=", "*="
+	val string
+}
+
+func (m attrMatcher) match(node *Node) bool {
+	attr, ok := node.Attributes[m.key]
+	if !ok {
+		return false
+	}
+	if m.op == "" {
+		return true
+	}
+	switch m.op {
+	case "=":
+		return attr.Val == m.val
+	case "~=":
+		for _, tok := range strings.Fields(attr.Val) {
+			if tok == m.val {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return attr.Val == m.val || strings.HasPrefix(attr.Val, m.val+"-")
+	case "^=":
+		return m.val != "" && strings.HasPrefix(attr.Val, m.val)
+	case "$=":
+		return m.val != "" && strings.HasSuffix(attr.Val, m.val)
+	case "*=":
+		return m.val != "" && strings.Contains(attr.Val, m.val)
+	}
+	return false
+}
+
+// notMatcher implements :not(selector).
+type notMatcher struct{ inner *Selector }
+
+func (m notMatcher) match(node *Node) bool { return !m.inner.Match(node) }
+
+// emptyMatcher implements :empty.
+type emptyMatcher struct{}
+
+func (emptyMatcher) match(node *Node) bool { return len(node.Children) == 0 }
+
+// firstChildMatcher implements :first-child.
+type firstChildMatcher struct{}
+
+func (firstChildMatcher) match(node *Node) bool { return node.prevElementSibling() == nil }
+
+// lastChildMatcher implements :last-child.
+type lastChildMatcher struct{}
+
+func (lastChildMatcher) match(node *Node) bool { return node.nextElementSibling() == nil }
+
+// nthChildMatcher implements :nth-child(an+b).
+type nthChildMatcher struct{ a, b int }
+
+func (m nthChildMatcher) match(node *Node) bool {
+	if node.Parent == nil {
+		return false
+	}
+	index := 1
+	for s := node.prevElementSibling(); s != nil; s = s.prevElementSibling() {
+		index++
+	}
+	if m.a == 0 {
+		return index == m.b
+	}
+	diff := index - m.b
+	return diff%m.a == 0 && diff/m.a >= 0
+}
+
+// nextElementSibling returns the element immediately after node among its
+// parent's children, skipping over any text/comment nodes, or nil if
+// there isn't one.
+func (node *Node) nextElementSibling() *Node {
+	for s := node.NextSibling; s != nil; s = s.NextSibling {
+		if s.isElement() {
+			return s
+		}
+	}
+	return nil
+}
+
+// selectorParser is a small recursive-descent parser turning a CSS
+// selector string into a list of complexSelectors.
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *selectorParser) parseSelectorList() ([]complexSelector, error) {
+	var list []complexSelector
+	for {
+		p.skipSpace()
+		cs, err := p.parseComplexSelector()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, cs)
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	p.skipSpace()
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("goelement: unexpected %q at %d in selector %q", p.input[p.pos:], p.pos, p.input)
+	}
+	return list, nil
+}
+
+func (p *selectorParser) parseComplexSelector() (complexSelector, error) {
+	var cs complexSelector
+	first, err := p.parseCompound()
+	if err != nil {
+		return cs, err
+	}
+	cs.compounds = append(cs.compounds, first)
+
+	for {
+		hadSpace := p.skipSpace()
+		comb := byte(' ')
+		switch p.peek() {
+		case '>', '+', '~':
+			comb = p.input[p.pos]
+			p.pos++
+			p.skipSpace()
+		case ',', 0:
+			return cs, nil
+		default:
+			if !hadSpace {
+				return cs, nil
+			}
+		}
+		next, err := p.parseCompound()
+		if err != nil {
+			return cs, err
+		}
+		cs.compounds = append(cs.compounds, next)
+		cs.combinators = append(cs.combinators, comb)
+	}
+}
+
+func (p *selectorParser) parseCompound() (compound, error) {
+	var c compound
+	sawType := false
+	for {
+		switch p.peek() {
+		case '*':
+			p.pos++
+			c.matchers = append(c.matchers, typeMatcher{tag: "*"})
+			sawType = true
+		case '#':
+			p.pos++
+			name := p.parseIdent()
+			c.matchers = append(c.matchers, idMatcher{id: name})
+		case '.':
+			p.pos++
+			name := p.parseIdent()
+			c.matchers = append(c.matchers, classMatcher{class: name})
+		case '[':
+			m, err := p.parseAttr()
+			if err != nil {
+				return c, err
+			}
+			c.matchers = append(c.matchers, m)
+		case ':':
+			m, err := p.parsePseudo()
+			if err != nil {
+				return c, err
+			}
+			c.matchers = append(c.matchers, m)
+		default:
+			if !sawType && isIdentStart(p.peek()) {
+				name := p.parseIdent()
+				c.matchers = append(c.matchers, typeMatcher{tag: name})
+				sawType = true
+				continue
+			}
+			if len(c.matchers) == 0 {
+				return c, fmt.Errorf("goelement: expected selector at %d in %q", p.pos, p.input)
+			}
+			return c, nil
+		}
+	}
+}
+
+func (p *selectorParser) parseAttr() (matcher, error) {
+	p.pos++ // consume '['
+	p.skipSpace()
+	key := p.parseIdent()
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return attrMatcher{key: key}, nil
+	}
+	op := ""
+	switch p.peek() {
+	case '~', '|', '^', '$', '*':
+		op = string(p.input[p.pos])
+		p.pos++
+		if p.peek() != '=' {
+			return nil, fmt.Errorf("goelement: malformed attribute selector at %d in %q", p.pos, p.input)
+		}
+	case '=':
+		op = ""
+	default:
+		return nil, fmt.Errorf("goelement: malformed attribute selector at %d in %q", p.pos, p.input)
+	}
+	if p.peek() != '=' {
+		return nil, fmt.Errorf("goelement: malformed attribute selector at %d in %q", p.pos, p.input)
+	}
+	p.pos++
+	op += "="
+	p.skipSpace()
+	val := p.parseAttrValue()
+	p.skipSpace()
+	if p.peek() != ']' {
+		return nil, fmt.Errorf("goelement: unterminated attribute selector in %q", p.input)
+	}
+	p.pos++
+	return attrMatcher{key: key, op: op, val: val}, nil
+}
+
+func (p *selectorParser) parseAttrValue() string {
+	if p.peek() == '"' || p.peek() == '\'' {
+		quote := p.input[p.pos]
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] != quote {
+			p.pos++
+		}
+		val := p.input[start:p.pos]
+		if p.pos < len(p.input) {
+			p.pos++
+		}
+		return val
+	}
+	return p.parseIdent()
+}
+
+func (p *selectorParser) parsePseudo() (matcher, error) {
+	p.pos++ // consume ':'
+	name := p.parseIdent()
+	switch name {
+	case "first-child":
+		return firstChildMatcher{}, nil
+	case "last-child":
+		return lastChildMatcher{}, nil
+	case "empty":
+		return emptyMatcher{}, nil
+	case "nth-child":
+		if p.peek() != '(' {
+			return nil, fmt.Errorf("goelement: :nth-child requires an argument in %q", p.input)
+		}
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] != ')' {
+			p.pos++
+		}
+		expr := strings.TrimSpace(p.input[start:p.pos])
+		if p.pos < len(p.input) {
+			p.pos++
+		}
+		a, b, err := parseNth(expr)
+		if err != nil {
+			return nil, err
+		}
+		return nthChildMatcher{a: a, b: b}, nil
+	case "not":
+		if p.peek() != '(' {
+			return nil, fmt.Errorf("goelement: :not requires an argument in %q", p.input)
+		}
+		p.pos++
+		start := p.pos
+		depth := 1
+		for p.pos < len(p.input) && depth > 0 {
+			switch p.input[p.pos] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					continue
+				}
+			}
+			p.pos++
+		}
+		inner := p.input[start:p.pos]
+		if p.pos < len(p.input) {
+			p.pos++
+		}
+		sel, err := CompileSelector(inner)
+		if err != nil {
+			return nil, err
+		}
+		return notMatcher{inner: sel}, nil
+	}
+	return nil, fmt.Errorf("goelement: unsupported pseudo-class %q in %q", name, p.input)
+}
+
+// parseNth parses the an+b microsyntax used by :nth-child, plus the
+// "even"/"odd" keywords.
+func parseNth(expr string) (a, b int, err error) {
+	expr = strings.ToLower(strings.ReplaceAll(expr, " ", ""))
+	switch expr {
+	case "even":
+		return 2, 0, nil
+	case "odd":
+		return 2, 1, nil
+	}
+	if !strings.Contains(expr, "n") {
+		n, err := strconv.Atoi(expr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("goelement: invalid :nth-child argument %q", expr)
+		}
+		return 0, n, nil
+	}
+	parts := strings.SplitN(expr, "n", 2)
+	aPart := parts[0]
+	switch aPart {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		a, err = strconv.Atoi(aPart)
+		if err != nil {
+			return 0, 0, fmt.Errorf("goelement: invalid :nth-child argument %q", expr)
+		}
+	}
+	bPart := parts[1]
+	if bPart == "" {
+		b = 0
+	} else {
+		b, err = strconv.Atoi(bPart)
+		if err != nil {
+			return 0, 0, fmt.Errorf("goelement: invalid :nth-child argument %q", expr)
+		}
+	}
+	return a, b, nil
+}
+
+func (p *selectorParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+	return p.pos != start
+}
+
+func (p *selectorParser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.input) && isIdentChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '-' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
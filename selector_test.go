@@ -0,0 +1,94 @@
+package goelement_test
+
+import (
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+)
+
+const selectorTestHTML = `
+<body>
+	<div id="main" class="wrap outer">
+		<h3>Title</h3>
+		<p class="item first">one</p>
+		<p class="item">two</p>
+		<p class="item">three</p>
+		<span data-role="note">aside</span>
+	</div>
+	<div class="empty"></div>
+</body>
+`
+
+func TestQueryAllTypeClassID(t *testing.T) {
+	root := goelement.ParseFromString(selectorTestHTML)
+
+	if got := len(root.QueryAll("p")); got != 3 {
+		t.Fatalf("QueryAll(p) = %d nodes, want 3", got)
+	}
+	if got := len(root.QueryAll(".item")); got != 3 {
+		t.Fatalf("QueryAll(.item) = %d nodes, want 3", got)
+	}
+	if got := root.Query("#main"); got == nil || !got.HasClass("wrap") {
+		t.Fatalf("Query(#main) = %v, want the div.wrap", got)
+	}
+}
+
+func TestQueryAttrOperators(t *testing.T) {
+	root := goelement.ParseFromString(selectorTestHTML)
+
+	if got := root.Query("[data-role=note]"); got == nil {
+		t.Fatalf("[data-role=note] should match the span")
+	}
+	if got := root.Query("[data-role^=no]"); got == nil {
+		t.Fatalf("[data-role^=no] should match (prefix)")
+	}
+	if got := root.Query("[data-role$=te]"); got == nil {
+		t.Fatalf("[data-role$=te] should match (suffix)")
+	}
+	if got := root.Query("[data-role*=ot]"); got == nil {
+		t.Fatalf("[data-role*=ot] should match (substring)")
+	}
+	if got := root.Query("[data-role=nope]"); got != nil {
+		t.Fatalf("[data-role=nope] should not match anything, got %v", got)
+	}
+}
+
+func TestQueryCombinators(t *testing.T) {
+	root := goelement.ParseFromString(selectorTestHTML)
+
+	if got := len(root.QueryAll("div p")); got != 3 {
+		t.Fatalf("QueryAll(div p) (descendant) = %d, want 3", got)
+	}
+	if got := len(root.QueryAll("div > p")); got != 3 {
+		t.Fatalf("QueryAll(div > p) (child) = %d, want 3", got)
+	}
+	if got := root.Query("h3 + p"); got == nil || got.Text() != "one" {
+		t.Fatalf("Query(h3 + p) (adjacent) = %v, want the first <p>", got)
+	}
+	if got := len(root.QueryAll("h3 ~ p")); got != 3 {
+		t.Fatalf("QueryAll(h3 ~ p) (general sibling) = %d, want 3", got)
+	}
+}
+
+func TestQueryPseudoClasses(t *testing.T) {
+	root := goelement.ParseFromString(selectorTestHTML)
+
+	if got := root.Query("p:first-child"); got != nil {
+		t.Fatalf("p:first-child should not match (h3 is the actual first child), got %v", got)
+	}
+	if got := root.Query("h3:first-child"); got == nil {
+		t.Fatalf("h3:first-child should match")
+	}
+	if got := root.Query("span:last-child"); got == nil {
+		t.Fatalf("span:last-child should match")
+	}
+	if got := len(root.QueryAll(".item:nth-child(even)")); got != 2 {
+		t.Fatalf("QueryAll(.item:nth-child(even)) = %d, want 2 (the 2nd and 4th children)", got)
+	}
+	if got := root.Query("div.empty:empty"); got == nil {
+		t.Fatalf("div.empty:empty should match")
+	}
+	if got := root.Query("p:not(.first)"); got == nil || got.Text() != "two" {
+		t.Fatalf("p:not(.first) should match the second <p>, got %v", got)
+	}
+}
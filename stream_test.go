@@ -0,0 +1,136 @@
+package goelement_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+	"golang.org/x/net/html"
+)
+
+type recordingHandler struct {
+	starts   []string
+	texts    []string
+	subtrees []*goelement.Node
+	skip     map[string]bool
+	capture  map[string]bool
+}
+
+func (h *recordingHandler) OnStartTag(path []string, tok html.Token) goelement.Action {
+	h.starts = append(h.starts, strings.Join(path, "/"))
+	if h.skip[tok.Data] {
+		return goelement.Skip
+	}
+	if h.capture[tok.Data] {
+		return goelement.Capture
+	}
+	return goelement.Continue
+}
+func (h *recordingHandler) OnSelfClosing(path []string, tok html.Token) goelement.Action {
+	return goelement.Continue
+}
+func (h *recordingHandler) OnEndTag(path []string) {}
+func (h *recordingHandler) OnText(path []string, text string) {
+	if t := strings.TrimSpace(text); t != "" {
+		h.texts = append(h.texts, t)
+	}
+}
+func (h *recordingHandler) OnSubtree(n *goelement.Node) {
+	h.subtrees = append(h.subtrees, n)
+}
+
+func TestParseStreamSkipAndCapture(t *testing.T) {
+	src := `<html><head><script>var x = "<p>fake</p>";</script></head>` +
+		`<body><p>hello <b>world</b></p><div>after</div></body></html>`
+
+	h := &recordingHandler{skip: map[string]bool{"script": true}, capture: map[string]bool{"p": true}}
+	if err := goelement.ParseStream(strings.NewReader(src), h); err != nil {
+		t.Fatalf("ParseStream: %v", err)
+	}
+
+	if len(h.subtrees) != 1 {
+		t.Fatalf("got %d captured subtrees, want 1", len(h.subtrees))
+	}
+	if got := h.subtrees[0].HTML(); !strings.Contains(got, "hello") || !strings.Contains(got, "<b>world</b>") {
+		t.Fatalf("captured subtree = %q, missing expected content", got)
+	}
+	for _, s := range h.starts {
+		if s == "html/head/script/p" {
+			t.Fatalf("descended into the skipped <script>'s fake markup: %v", h.starts)
+		}
+	}
+	found := false
+	for _, s := range h.texts {
+		if s == "after" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected to see the <div> text after the captured <p>, got %v", h.texts)
+	}
+}
+
+func TestParseStreamStop(t *testing.T) {
+	src := `<ul><li>one</li><li>two</li><li>three</li></ul>`
+	h := &recordingHandler{capture: map[string]bool{"li": true}}
+	stopAfter := 2
+	stoppingHandler := &stopOnNthCapture{recordingHandler: h, stopAfter: stopAfter}
+
+	if err := goelement.ParseStream(strings.NewReader(src), stoppingHandler); err != nil {
+		t.Fatalf("ParseStream: %v", err)
+	}
+	if len(h.subtrees) != stopAfter {
+		t.Fatalf("got %d subtrees, want exactly %d (parsing should stop after that)", len(h.subtrees), stopAfter)
+	}
+}
+
+// stopOnNthCapture wraps recordingHandler to return Stop once enough
+// subtrees have been captured, exercising ParseStream's early-abort path.
+type stopOnNthCapture struct {
+	*recordingHandler
+	stopAfter int
+}
+
+func (h *stopOnNthCapture) OnStartTag(path []string, tok html.Token) goelement.Action {
+	if len(h.subtrees) >= h.stopAfter {
+		return goelement.Stop
+	}
+	return h.recordingHandler.OnStartTag(path, tok)
+}
+
+func TestParseStreamSelectorDescendantCombinator(t *testing.T) {
+	src := `<body><div class="a"><p class="x">one</p></div>` +
+		`<div class="b"><p class="x">two</p><p>three</p></div></body>`
+
+	var got []string
+	err := goelement.ParseStreamSelector(strings.NewReader(src), "div.b p.x", func(n *goelement.Node) error {
+		got = append(got, n.HTML())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStreamSelector: %v", err)
+	}
+	if len(got) != 1 || !strings.Contains(got[0], "two") {
+		t.Fatalf("got %v, want just the matching p.x inside div.b", got)
+	}
+}
+
+func TestParseStreamSelectorStopsOnHandlerError(t *testing.T) {
+	src := `<body><p>one</p><p>two</p><p>three</p></body>`
+	errStop := errors.New("stop here")
+	count := 0
+	err := goelement.ParseStreamSelector(strings.NewReader(src), "p", func(n *goelement.Node) error {
+		count++
+		if count == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want errStop", err)
+	}
+	if count != 2 {
+		t.Fatalf("stopped after %d matches, want 2", count)
+	}
+}
@@ -0,0 +1,38 @@
+package goelement
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// Text returns the concatenated text of node and all of its descendants,
+// in document order, with no whitespace changes.
+func (node *Node) Text() string {
+	var b strings.Builder
+	node.Walk(func(n *Node) bool {
+		if n.Type == html.TextToken {
+			b.WriteString(n.Data)
+		}
+		return true
+	})
+	return b.String()
+}
+
+// TextNormalized returns node's Text with runs of whitespace collapsed to
+// a single space and the result trimmed, the way a browser would render
+// it for display.
+func (node *Node) TextNormalized() string {
+	return strings.Join(strings.Fields(node.Text()), " ")
+}
+
+// OwnText returns the text of node's direct text children only, not
+// descending into child elements.
+func (node *Node) OwnText() string {
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextToken {
+			b.WriteString(child.Data)
+		}
+	}
+	return b.String()
+}
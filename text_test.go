@@ -0,0 +1,23 @@
+package goelement_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+)
+
+func TestTextVariants(t *testing.T) {
+	root := goelement.ParseFromString(`<div>  hello <b>world</b>  <!-- c -->  bye  </div>`)
+	div := root.FindTag("div")
+
+	if got := strings.Join(strings.Fields(div.Text()), " "); got != "hello world bye" {
+		t.Fatalf("Text() (collapsed for comparison) = %q, want \"hello world bye\"", got)
+	}
+	if got := div.TextNormalized(); got != "hello world bye" {
+		t.Fatalf("TextNormalized() = %q, want \"hello world bye\"", got)
+	}
+	if got := strings.Join(strings.Fields(div.OwnText()), " "); got != "hello bye" {
+		t.Fatalf("OwnText() = %q, want \"hello bye\" (no descent into <b>)", got)
+	}
+}
@@ -0,0 +1,79 @@
+package goelement_test
+
+import (
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+	"golang.org/x/net/html"
+)
+
+const walkTestHTML = `<div><h1>Title</h1><p>one</p><p>two</p></div>`
+
+func TestSiblingNavigation(t *testing.T) {
+	root := goelement.ParseFromString(walkTestHTML)
+	div := root.FindTag("div")
+
+	h1 := div.FirstChild
+	if h1 == nil || h1.Data != "h1" {
+		t.Fatalf("FirstChild = %v, want the <h1>", h1)
+	}
+	if h1.PrevSibling != nil {
+		t.Fatalf("FirstChild should have no PrevSibling")
+	}
+
+	firstP := h1.NextSibling
+	if firstP == nil || firstP.Data != "p" || firstP.Text() != "one" {
+		t.Fatalf("h1.NextSibling = %v, want the first <p>", firstP)
+	}
+	if firstP.PrevSibling != h1 {
+		t.Fatalf("first <p>'s PrevSibling should be the <h1>")
+	}
+
+	secondP := firstP.NextSibling
+	if secondP == nil || secondP.Text() != "two" {
+		t.Fatalf("firstP.NextSibling = %v, want the second <p>", secondP)
+	}
+	if div.LastChild != secondP {
+		t.Fatalf("div.LastChild should be the second <p>")
+	}
+	if secondP.NextSibling != nil {
+		t.Fatalf("LastChild should have no NextSibling")
+	}
+}
+
+func TestWalkPreOrderAndEarlyExit(t *testing.T) {
+	root := goelement.ParseFromString(walkTestHTML)
+	div := root.FindTag("div")
+
+	var tags []string
+	complete := div.Walk(func(n *goelement.Node) bool {
+		if n.Type == html.StartTagToken {
+			tags = append(tags, n.Data)
+		}
+		return true
+	})
+	if !complete {
+		t.Fatalf("Walk should report completion when fn never returns false")
+	}
+	want := []string{"div", "h1", "p", "p"}
+	if len(tags) != len(want) {
+		t.Fatalf("got tags %v, want %v", tags, want)
+	}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Fatalf("got tags %v, want %v", tags, want)
+		}
+	}
+
+	visited := 0
+	complete = div.Walk(func(n *goelement.Node) bool {
+		visited++
+		return n.Data != "h1"
+	})
+	if complete {
+		t.Fatalf("Walk should report incompletion when fn stops early")
+	}
+	if visited != 2 {
+		t.Fatalf("Walk visited %d nodes before stopping, want 2 (div, h1)", visited)
+	}
+}
@@ -0,0 +1,598 @@
+package goelement
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// XPathValueType identifies the dynamic type of an XPathResult, mirroring
+// the four XPath 1.0 data types.
+type XPathValueType int
+
+const (
+	XPathNodeSet XPathValueType = iota
+	XPathNumber
+	XPathString
+	XPathBoolean
+)
+
+// XPathResult is the result of evaluating an XPath expression. Exactly
+// one of Nodes/Num/Str/Bool is meaningful, selected by Type.
+type XPathResult struct {
+	Type  XPathValueType
+	Nodes []*Node
+	Num   float64
+	Str   string
+	Bool  bool
+}
+
+// String coerces the result to a string, following the XPath 1.0
+// conversion rules (a node-set converts via the string-value of its
+// first node in document order).
+func (r XPathResult) String() string {
+	switch r.Type {
+	case XPathNodeSet:
+		if len(r.Nodes) == 0 {
+			return ""
+		}
+		return xpathStringValue(r.Nodes[0])
+	case XPathNumber:
+		return strconv.FormatFloat(r.Num, 'g', -1, 64)
+	case XPathBoolean:
+		if r.Bool {
+			return "true"
+		}
+		return "false"
+	default:
+		return r.Str
+	}
+}
+
+// Number coerces the result to a float64.
+func (r XPathResult) Number() float64 {
+	switch r.Type {
+	case XPathNumber:
+		return r.Num
+	case XPathBoolean:
+		if r.Bool {
+			return 1
+		}
+		return 0
+	default:
+		n, err := strconv.ParseFloat(strings.TrimSpace(r.String()), 64)
+		if err != nil {
+			return nan()
+		}
+		return n
+	}
+}
+
+// Boolean coerces the result to a bool.
+func (r XPathResult) Boolean() bool {
+	switch r.Type {
+	case XPathBoolean:
+		return r.Bool
+	case XPathNodeSet:
+		return len(r.Nodes) > 0
+	case XPathNumber:
+		return r.Num != 0 && r.Num == r.Num // exclude NaN
+	default:
+		return r.Str != ""
+	}
+}
+
+func nan() float64 {
+	var zero float64
+	return zero / zero
+}
+
+// XPath evaluates expr and returns the resulting node-set in document
+// order. It is an error for expr to evaluate to anything other than a
+// node-set.
+func (node *Node) XPath(expr string) ([]*Node, error) {
+	result, err := node.XPathEval(expr)
+	if err != nil {
+		return nil, err
+	}
+	if result.Type != XPathNodeSet {
+		return nil, fmt.Errorf("goelement: xpath expression %q did not evaluate to a node-set", expr)
+	}
+	return result.Nodes, nil
+}
+
+// XPathEval evaluates an XPath 1.0 expression (a practical subset of the
+// language) with node as the context node and returns its result.
+func (node *Node) XPathEval(expr string) (XPathResult, error) {
+	parsed, err := parseXPathExpr(expr)
+	if err != nil {
+		return XPathResult{}, err
+	}
+	top := node
+	for top.Parent != nil {
+		top = top.Parent
+	}
+	// If top is already dive()'s synthetic top-level container (a
+	// document with a leading doctype/text alongside its root element),
+	// it can anchor absolute paths directly; otherwise wrap the single
+	// real root node the same way so docRoot.FirstChild is always the
+	// thing absolute paths descend from.
+	docRoot := top
+	if top.Type != containerTokenType {
+		docRoot = &Node{FirstChild: top, LastChild: top}
+	}
+	ctx := &xpathContext{
+		node:     node,
+		position: 1,
+		size:     1,
+		docRoot:  docRoot,
+		order:    xpathOrderIndex(docRoot),
+	}
+	return parsed.eval(ctx)
+}
+
+// xpathContext carries the information an XPath expression needs while
+// it's being evaluated: the current context node plus its position/size
+// within whatever node-set produced it, a synthetic document root to
+// anchor absolute paths, and a precomputed document-order index.
+type xpathContext struct {
+	node     *Node
+	position int
+	size     int
+	docRoot  *Node
+	order    map[*Node]int
+}
+
+// xpathOrderIndex walks root in pre-order and records each node's
+// position, so node-sets gathered via reverse axes can be re-sorted into
+// document order.
+func xpathOrderIndex(root *Node) map[*Node]int {
+	order := make(map[*Node]int)
+	i := 0
+	root.Walk(func(n *Node) bool {
+		order[n] = i
+		i++
+		return true
+	})
+	return order
+}
+
+// xpathStringValue computes the XPath string-value of a node: the
+// attribute value for a synthetic attribute node (see attributeStep),
+// the text for a text node, and the concatenation of all descendant text
+// otherwise.
+func xpathStringValue(node *Node) string {
+	if isXPathAttributeNode(node) {
+		return node.Data
+	}
+	if node.Type == html.TextToken {
+		return node.Data
+	}
+	var b strings.Builder
+	node.Walk(func(n *Node) bool {
+		if n.Type == html.TextToken {
+			b.WriteString(n.Data)
+		}
+		return true
+	})
+	return b.String()
+}
+
+// isXPathAttributeNode reports whether node is a synthetic node created
+// by the attribute axis (@name). Such nodes carry exactly the one
+// html.Attribute they represent and are never linked into the tree.
+func isXPathAttributeNode(node *Node) bool {
+	return node.Type == html.TextToken && len(node.Attr) == 1 && node.Data == node.Attr[0].Val
+}
+
+// xpathNodeName returns the name() of a node: the attribute key for a
+// synthetic attribute node, or the tag name otherwise.
+func xpathNodeName(node *Node) string {
+	if isXPathAttributeNode(node) {
+		return node.Attr[0].Key
+	}
+	return node.Data
+}
+
+// ---- AST ----
+
+// xpathExpr is any evaluable XPath expression: a location path, a
+// literal, a function call, or an operator applied to sub-expressions.
+type xpathExpr interface {
+	eval(ctx *xpathContext) (XPathResult, error)
+}
+
+// xpathPath is a (possibly absolute) sequence of steps.
+type xpathPath struct {
+	absolute bool
+	steps    []xpathStep
+}
+
+// xpathStep is one "axis::nodeTest[predicates]" component of a path.
+type xpathStep struct {
+	axis       string
+	test       xpathNodeTest
+	predicates []xpathExpr
+}
+
+// xpathNodeTest is the node-test part of a step: a tag name, "*", or one
+// of the node-type tests text()/node().
+type xpathNodeTest struct {
+	kind string // "name", "*", "text()", "node()"
+	name string
+}
+
+func (p xpathPath) eval(ctx *xpathContext) (XPathResult, error) {
+	current := []*Node{ctx.node}
+	if p.absolute {
+		current = []*Node{ctx.docRoot}
+	}
+	for _, step := range p.steps {
+		next, err := evalStep(ctx, current, step)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		current = next
+	}
+	return XPathResult{Type: XPathNodeSet, Nodes: current}, nil
+}
+
+func evalStep(ctx *xpathContext, current []*Node, step xpathStep) ([]*Node, error) {
+	seen := make(map[*Node]bool)
+	var result []*Node
+	for _, contextNode := range current {
+		axisNodes, err := xpathAxis(contextNode, step.axis)
+		if err != nil {
+			return nil, err
+		}
+		nodes := filterByNodeTest(axisNodes, step.test)
+		for _, pred := range step.predicates {
+			nodes, err = filterByPredicate(ctx, nodes, pred)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, candidate := range nodes {
+			if !seen[candidate] {
+				seen[candidate] = true
+				result = append(result, candidate)
+			}
+		}
+	}
+	sortByDocumentOrder(result, ctx.order)
+	return result, nil
+}
+
+// filterByPredicate evaluates pred against nodes, with position/size
+// scoped to this node-set (as XPath 1.0 requires: each predicate in a
+// step sees the node-set produced by the predicate before it, not the
+// step's original axis+node-test result).
+func filterByPredicate(ctx *xpathContext, nodes []*Node, pred xpathExpr) ([]*Node, error) {
+	size := len(nodes)
+	var kept []*Node
+	for i, candidate := range nodes {
+		val, err := pred.eval(&xpathContext{node: candidate, position: i + 1, size: size, docRoot: ctx.docRoot, order: ctx.order})
+		if err != nil {
+			return nil, err
+		}
+		keep := val.Boolean()
+		if val.Type == XPathNumber {
+			keep = val.Num == float64(i+1)
+		}
+		if keep {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept, nil
+}
+
+func sortByDocumentOrder(nodes []*Node, order map[*Node]int) {
+	for i := 1; i < len(nodes); i++ {
+		for j := i; j > 0 && order[nodes[j-1]] > order[nodes[j]]; j-- {
+			nodes[j-1], nodes[j] = nodes[j], nodes[j-1]
+		}
+	}
+}
+
+// xpathAxis returns the nodes reachable from node along axis, in the
+// order that axis naturally produces them (document order for forward
+// axes, reverse document order for reverse axes).
+func xpathAxis(node *Node, axis string) ([]*Node, error) {
+	switch axis {
+	case "self":
+		return []*Node{node}, nil
+	case "child":
+		var nodes []*Node
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+		return nodes, nil
+	case "parent":
+		if node.Parent == nil {
+			return nil, nil
+		}
+		return []*Node{node.Parent}, nil
+	case "descendant":
+		var nodes []*Node
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			c.Walk(func(n *Node) bool {
+				nodes = append(nodes, n)
+				return true
+			})
+		}
+		return nodes, nil
+	case "descendant-or-self":
+		var nodes []*Node
+		node.Walk(func(n *Node) bool {
+			nodes = append(nodes, n)
+			return true
+		})
+		return nodes, nil
+	case "ancestor":
+		var nodes []*Node
+		for p := node.Parent; p != nil; p = p.Parent {
+			nodes = append(nodes, p)
+		}
+		return nodes, nil
+	case "following-sibling":
+		var nodes []*Node
+		for s := node.NextSibling; s != nil; s = s.NextSibling {
+			nodes = append(nodes, s)
+		}
+		return nodes, nil
+	case "preceding-sibling":
+		var nodes []*Node
+		for s := node.PrevSibling; s != nil; s = s.PrevSibling {
+			nodes = append(nodes, s)
+		}
+		return nodes, nil
+	case "attribute":
+		var nodes []*Node
+		for _, attr := range node.Attr {
+			attr := attr
+			nodes = append(nodes, &Node{Token: html.Token{Type: html.TextToken, Data: attr.Val, Attr: []html.Attribute{attr}}, Parent: node})
+		}
+		return nodes, nil
+	}
+	return nil, fmt.Errorf("goelement: unsupported xpath axis %q", axis)
+}
+
+func filterByNodeTest(nodes []*Node, test xpathNodeTest) []*Node {
+	var out []*Node
+	for _, n := range nodes {
+		if matchesNodeTest(n, test) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func matchesNodeTest(node *Node, test xpathNodeTest) bool {
+	switch test.kind {
+	case "node()":
+		return true
+	case "text()":
+		return node.Type == html.TextToken && !isXPathAttributeNode(node)
+	case "*":
+		return node.isElement() || isXPathAttributeNode(node)
+	case "name":
+		if isXPathAttributeNode(node) {
+			return node.Attr[0].Key == test.name
+		}
+		return node.isElement() && node.Data == test.name
+	}
+	return false
+}
+
+// ---- literals, function calls and operators ----
+
+type xpathNumberLit float64
+
+func (n xpathNumberLit) eval(*xpathContext) (XPathResult, error) {
+	return XPathResult{Type: XPathNumber, Num: float64(n)}, nil
+}
+
+type xpathStringLit string
+
+func (s xpathStringLit) eval(*xpathContext) (XPathResult, error) {
+	return XPathResult{Type: XPathString, Str: string(s)}, nil
+}
+
+type xpathFuncCall struct {
+	name string
+	args []xpathExpr
+}
+
+func (f xpathFuncCall) eval(ctx *xpathContext) (XPathResult, error) {
+	arg := func(i int) (XPathResult, error) { return f.args[i].eval(ctx) }
+
+	switch f.name {
+	case "position":
+		return XPathResult{Type: XPathNumber, Num: float64(ctx.position)}, nil
+	case "last":
+		return XPathResult{Type: XPathNumber, Num: float64(ctx.size)}, nil
+	case "count":
+		v, err := arg(0)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathNumber, Num: float64(len(v.Nodes))}, nil
+	case "name":
+		if len(f.args) == 0 {
+			return XPathResult{Type: XPathString, Str: xpathNodeName(ctx.node)}, nil
+		}
+		v, err := arg(0)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		if len(v.Nodes) == 0 {
+			return XPathResult{Type: XPathString}, nil
+		}
+		return XPathResult{Type: XPathString, Str: xpathNodeName(v.Nodes[0])}, nil
+	case "text":
+		return XPathResult{Type: XPathString, Str: xpathStringValue(ctx.node)}, nil
+	case "contains":
+		a, err := arg(0)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		b, err := arg(1)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathBoolean, Bool: strings.Contains(a.String(), b.String())}, nil
+	case "starts-with":
+		a, err := arg(0)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		b, err := arg(1)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathBoolean, Bool: strings.HasPrefix(a.String(), b.String())}, nil
+	case "normalize-space":
+		var s string
+		if len(f.args) == 0 {
+			s = xpathStringValue(ctx.node)
+		} else {
+			v, err := arg(0)
+			if err != nil {
+				return XPathResult{}, err
+			}
+			s = v.String()
+		}
+		return XPathResult{Type: XPathString, Str: strings.Join(strings.Fields(s), " ")}, nil
+	case "string-length":
+		var s string
+		if len(f.args) == 0 {
+			s = xpathStringValue(ctx.node)
+		} else {
+			v, err := arg(0)
+			if err != nil {
+				return XPathResult{}, err
+			}
+			s = v.String()
+		}
+		return XPathResult{Type: XPathNumber, Num: float64(len([]rune(s)))}, nil
+	case "not":
+		v, err := arg(0)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathBoolean, Bool: !v.Boolean()}, nil
+	}
+	return XPathResult{}, fmt.Errorf("goelement: unsupported xpath function %q", f.name)
+}
+
+// xpathBinaryOp implements comparison, boolean and arithmetic operators.
+type xpathBinaryOp struct {
+	op          string
+	left, right xpathExpr
+}
+
+func (b xpathBinaryOp) eval(ctx *xpathContext) (XPathResult, error) {
+	l, err := b.left.eval(ctx)
+	if err != nil {
+		return XPathResult{}, err
+	}
+	switch b.op {
+	case "and":
+		if !l.Boolean() {
+			return XPathResult{Type: XPathBoolean, Bool: false}, nil
+		}
+		r, err := b.right.eval(ctx)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathBoolean, Bool: r.Boolean()}, nil
+	case "or":
+		if l.Boolean() {
+			return XPathResult{Type: XPathBoolean, Bool: true}, nil
+		}
+		r, err := b.right.eval(ctx)
+		if err != nil {
+			return XPathResult{}, err
+		}
+		return XPathResult{Type: XPathBoolean, Bool: r.Boolean()}, nil
+	}
+
+	r, err := b.right.eval(ctx)
+	if err != nil {
+		return XPathResult{}, err
+	}
+
+	switch b.op {
+	case "=", "!=":
+		eq := xpathEquals(l, r)
+		if b.op == "!=" {
+			eq = !eq
+		}
+		return XPathResult{Type: XPathBoolean, Bool: eq}, nil
+	case "<":
+		return XPathResult{Type: XPathBoolean, Bool: l.Number() < r.Number()}, nil
+	case "<=":
+		return XPathResult{Type: XPathBoolean, Bool: l.Number() <= r.Number()}, nil
+	case ">":
+		return XPathResult{Type: XPathBoolean, Bool: l.Number() > r.Number()}, nil
+	case ">=":
+		return XPathResult{Type: XPathBoolean, Bool: l.Number() >= r.Number()}, nil
+	case "+":
+		return XPathResult{Type: XPathNumber, Num: l.Number() + r.Number()}, nil
+	case "-":
+		return XPathResult{Type: XPathNumber, Num: l.Number() - r.Number()}, nil
+	case "*":
+		return XPathResult{Type: XPathNumber, Num: l.Number() * r.Number()}, nil
+	case "div":
+		return XPathResult{Type: XPathNumber, Num: l.Number() / r.Number()}, nil
+	case "mod":
+		return XPathResult{Type: XPathNumber, Num: math.Mod(l.Number(), r.Number())}, nil
+	}
+	return XPathResult{}, fmt.Errorf("goelement: unsupported xpath operator %q", b.op)
+}
+
+// xpathEquals implements the XPath 1.0 equality rules closely enough for
+// the common cases: node-set vs string/number compares string-values,
+// otherwise operands are coerced to whichever concrete type is involved.
+func xpathEquals(l, r XPathResult) bool {
+	if l.Type == XPathNodeSet && r.Type == XPathNodeSet {
+		for _, a := range l.Nodes {
+			for _, b := range r.Nodes {
+				if xpathStringValue(a) == xpathStringValue(b) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if l.Type == XPathNodeSet || r.Type == XPathNodeSet {
+		nodeSet, other := l, r
+		if r.Type == XPathNodeSet {
+			nodeSet, other = r, l
+		}
+		for _, n := range nodeSet.Nodes {
+			s := xpathStringValue(n)
+			switch other.Type {
+			case XPathNumber:
+				v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err == nil && v == other.Num {
+					return true
+				}
+			default:
+				if s == other.String() {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if l.Type == XPathBoolean || r.Type == XPathBoolean {
+		return l.Boolean() == r.Boolean()
+	}
+	if l.Type == XPathNumber || r.Type == XPathNumber {
+		return l.Number() == r.Number()
+	}
+	return l.String() == r.String()
+}
@@ -0,0 +1,520 @@
+package goelement
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parseXPathExpr compiles an XPath 1.0 expression string into an AST.
+func parseXPathExpr(expr string) (xpathExpr, error) {
+	tokens, err := lexXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &xpathParser{tokens: tokens}
+	result, err := p.parseOrExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != xpEOF {
+		return nil, fmt.Errorf("goelement: unexpected token %q in xpath expression %q", p.peek().text, expr)
+	}
+	return result, nil
+}
+
+// ---- lexer ----
+
+type xpTokenKind int
+
+const (
+	xpEOF xpTokenKind = iota
+	xpIdent
+	xpNumber
+	xpString
+	xpSlash
+	xpSlashSlash
+	xpDot
+	xpDotDot
+	xpAt
+	xpStar
+	xpLParen
+	xpRParen
+	xpLBracket
+	xpRBracket
+	xpComma
+	xpColonColon
+	xpEq
+	xpNe
+	xpLt
+	xpLe
+	xpGt
+	xpGe
+	xpPlus
+	xpMinus
+	xpPipe
+)
+
+type xpToken struct {
+	kind xpTokenKind
+	text string
+}
+
+func lexXPath(expr string) ([]xpToken, error) {
+	var tokens []xpToken
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(expr) && expr[i+1] == '/':
+			tokens = append(tokens, xpToken{xpSlashSlash, "//"})
+			i += 2
+		case c == '/':
+			tokens = append(tokens, xpToken{xpSlash, "/"})
+			i++
+		case c == '.' && i+1 < len(expr) && expr[i+1] == '.':
+			tokens = append(tokens, xpToken{xpDotDot, ".."})
+			i += 2
+		case c == '.' && i+1 < len(expr) && isDigit(expr[i+1]):
+			start := i
+			i++
+			for i < len(expr) && isDigit(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, xpToken{xpNumber, expr[start:i]})
+		case c == '.':
+			tokens = append(tokens, xpToken{xpDot, "."})
+			i++
+		case c == '@':
+			tokens = append(tokens, xpToken{xpAt, "@"})
+			i++
+		case c == '*':
+			tokens = append(tokens, xpToken{xpStar, "*"})
+			i++
+		case c == '(':
+			tokens = append(tokens, xpToken{xpLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, xpToken{xpRParen, ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, xpToken{xpLBracket, "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, xpToken{xpRBracket, "]"})
+			i++
+		case c == ',':
+			tokens = append(tokens, xpToken{xpComma, ","})
+			i++
+		case c == '|':
+			tokens = append(tokens, xpToken{xpPipe, "|"})
+			i++
+		case c == ':' && i+1 < len(expr) && expr[i+1] == ':':
+			tokens = append(tokens, xpToken{xpColonColon, "::"})
+			i += 2
+		case c == '=':
+			tokens = append(tokens, xpToken{xpEq, "="})
+			i++
+		case c == '!' && i+1 < len(expr) && expr[i+1] == '=':
+			tokens = append(tokens, xpToken{xpNe, "!="})
+			i += 2
+		case c == '<' && i+1 < len(expr) && expr[i+1] == '=':
+			tokens = append(tokens, xpToken{xpLe, "<="})
+			i += 2
+		case c == '<':
+			tokens = append(tokens, xpToken{xpLt, "<"})
+			i++
+		case c == '>' && i+1 < len(expr) && expr[i+1] == '=':
+			tokens = append(tokens, xpToken{xpGe, ">="})
+			i += 2
+		case c == '>':
+			tokens = append(tokens, xpToken{xpGt, ">"})
+			i++
+		case c == '+':
+			tokens = append(tokens, xpToken{xpPlus, "+"})
+			i++
+		case c == '-':
+			tokens = append(tokens, xpToken{xpMinus, "-"})
+			i++
+		case c == '\'' || c == '"':
+			quote := c
+			start := i + 1
+			j := start
+			for j < len(expr) && expr[j] != quote {
+				j++
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("goelement: unterminated string literal in xpath expression %q", expr)
+			}
+			tokens = append(tokens, xpToken{xpString, expr[start:j]})
+			i = j + 1
+		case isDigit(c):
+			start := i
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, xpToken{xpNumber, expr[start:i]})
+		case isNameStart(c):
+			start := i
+			for i < len(expr) && isNameChar(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, xpToken{xpIdent, expr[start:i]})
+		default:
+			return nil, fmt.Errorf("goelement: unexpected character %q in xpath expression %q", string(c), expr)
+		}
+	}
+	return tokens, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c) || c == '-' || c == '.'
+}
+
+// ---- recursive-descent parser ----
+
+type xpathParser struct {
+	tokens []xpToken
+	pos    int
+}
+
+func (p *xpathParser) peek() xpToken {
+	if p.pos >= len(p.tokens) {
+		return xpToken{kind: xpEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) peekAt(offset int) xpToken {
+	if p.pos+offset >= len(p.tokens) {
+		return xpToken{kind: xpEOF}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *xpathParser) next() xpToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *xpathParser) expect(kind xpTokenKind, what string) (xpToken, error) {
+	tok := p.peek()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("goelement: expected %s at token %d (%q)", what, p.pos, tok.text)
+	}
+	return p.next(), nil
+}
+
+func (p *xpathParser) parseOrExpr() (xpathExpr, error) {
+	left, err := p.parseAndExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpIdent && p.peek().text == "or" {
+		p.next()
+		right, err := p.parseAndExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseAndExpr() (xpathExpr, error) {
+	left, err := p.parseEqualityExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpIdent && p.peek().text == "and" {
+		p.next()
+		right, err := p.parseEqualityExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseEqualityExpr() (xpathExpr, error) {
+	left, err := p.parseRelationalExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpEq || p.peek().kind == xpNe {
+		op := p.next()
+		right, err := p.parseRelationalExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: op.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseRelationalExpr() (xpathExpr, error) {
+	left, err := p.parseAdditiveExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpLt || p.peek().kind == xpLe || p.peek().kind == xpGt || p.peek().kind == xpGe {
+		op := p.next()
+		right, err := p.parseAdditiveExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: op.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseAdditiveExpr() (xpathExpr, error) {
+	left, err := p.parseMultiplicativeExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpPlus || p.peek().kind == xpMinus {
+		op := p.next()
+		right, err := p.parseMultiplicativeExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: op.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseMultiplicativeExpr() (xpathExpr, error) {
+	left, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpStar || (p.peek().kind == xpIdent && (p.peek().text == "div" || p.peek().text == "mod")) {
+		op := p.next()
+		opText := op.text
+		if op.kind == xpStar {
+			opText = "*"
+		}
+		right, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = xpathBinaryOp{op: opText, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseUnaryExpr() (xpathExpr, error) {
+	if p.peek().kind == xpMinus {
+		p.next()
+		inner, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return xpathBinaryOp{op: "-", left: xpathNumberLit(0), right: inner}, nil
+	}
+	return p.parsePathOrPrimary()
+}
+
+// parsePathOrPrimary parses a PrimaryExpr (number, string, parenthesized
+// expression, function call) or, failing that, a LocationPath.
+func (p *xpathParser) parsePathOrPrimary() (xpathExpr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case xpNumber:
+		p.next()
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("goelement: invalid number %q in xpath expression", tok.text)
+		}
+		return xpathNumberLit(n), nil
+	case xpString:
+		p.next()
+		return xpathStringLit(tok.text), nil
+	case xpLParen:
+		p.next()
+		inner, err := p.parseOrExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(xpRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case xpIdent:
+		if p.peekAt(1).kind == xpLParen {
+			return p.parseFunctionCall()
+		}
+	}
+	return p.parseLocationPath()
+}
+
+func (p *xpathParser) parseFunctionCall() (xpathExpr, error) {
+	name := p.next().text
+	if _, err := p.expect(xpLParen, "'('"); err != nil {
+		return nil, err
+	}
+	var args []xpathExpr
+	for p.peek().kind != xpRParen {
+		arg, err := p.parseOrExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.peek().kind == xpComma {
+			p.next()
+			continue
+		}
+		break
+	}
+	if _, err := p.expect(xpRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return xpathFuncCall{name: name, args: args}, nil
+}
+
+func (p *xpathParser) parseLocationPath() (xpathExpr, error) {
+	path := xpathPath{}
+	switch p.peek().kind {
+	case xpSlashSlash:
+		p.next()
+		path.absolute = true
+		path.steps = append(path.steps, descendantOrSelfStep())
+		steps, err := p.parseRelativeLocationPath()
+		if err != nil {
+			return nil, err
+		}
+		path.steps = append(path.steps, steps...)
+	case xpSlash:
+		p.next()
+		path.absolute = true
+		if p.atStepStart() {
+			steps, err := p.parseRelativeLocationPath()
+			if err != nil {
+				return nil, err
+			}
+			path.steps = steps
+		}
+	default:
+		steps, err := p.parseRelativeLocationPath()
+		if err != nil {
+			return nil, err
+		}
+		path.steps = steps
+	}
+	return path, nil
+}
+
+func (p *xpathParser) atStepStart() bool {
+	switch p.peek().kind {
+	case xpDot, xpDotDot, xpAt, xpStar, xpIdent:
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) parseRelativeLocationPath() ([]xpathStep, error) {
+	step, err := p.parseStep()
+	if err != nil {
+		return nil, err
+	}
+	steps := []xpathStep{step}
+	for p.peek().kind == xpSlash || p.peek().kind == xpSlashSlash {
+		if p.peek().kind == xpSlashSlash {
+			p.next()
+			steps = append(steps, descendantOrSelfStep())
+		} else {
+			p.next()
+		}
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func descendantOrSelfStep() xpathStep {
+	return xpathStep{axis: "descendant-or-self", test: xpathNodeTest{kind: "node()"}}
+}
+
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	switch p.peek().kind {
+	case xpDot:
+		p.next()
+		return xpathStep{axis: "self", test: xpathNodeTest{kind: "node()"}}, nil
+	case xpDotDot:
+		p.next()
+		return xpathStep{axis: "parent", test: xpathNodeTest{kind: "node()"}}, nil
+	}
+
+	axis := "child"
+	if p.peek().kind == xpAt {
+		p.next()
+		axis = "attribute"
+	} else if p.peek().kind == xpIdent && p.peekAt(1).kind == xpColonColon {
+		axis = p.next().text
+		p.next() // "::"
+	}
+
+	test, err := p.parseNodeTest()
+	if err != nil {
+		return xpathStep{}, err
+	}
+	predicates, err := p.parsePredicates()
+	if err != nil {
+		return xpathStep{}, err
+	}
+	return xpathStep{axis: axis, test: test, predicates: predicates}, nil
+}
+
+func (p *xpathParser) parseNodeTest() (xpathNodeTest, error) {
+	if p.peek().kind == xpStar {
+		p.next()
+		return xpathNodeTest{kind: "*"}, nil
+	}
+	tok, err := p.expect(xpIdent, "a node test")
+	if err != nil {
+		return xpathNodeTest{}, err
+	}
+	if p.peek().kind == xpLParen {
+		p.next()
+		if _, err := p.expect(xpRParen, "')'"); err != nil {
+			return xpathNodeTest{}, err
+		}
+		switch tok.text {
+		case "text":
+			return xpathNodeTest{kind: "text()"}, nil
+		case "node":
+			return xpathNodeTest{kind: "node()"}, nil
+		}
+		return xpathNodeTest{}, fmt.Errorf("goelement: unsupported node type test %q()", tok.text)
+	}
+	return xpathNodeTest{kind: "name", name: tok.text}, nil
+}
+
+func (p *xpathParser) parsePredicates() ([]xpathExpr, error) {
+	var predicates []xpathExpr
+	for p.peek().kind == xpLBracket {
+		p.next()
+		expr, err := p.parseOrExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(xpRBracket, "']'"); err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, expr)
+	}
+	return predicates, nil
+}
@@ -0,0 +1,119 @@
+package goelement_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Sjc1000/goelement"
+)
+
+const xpathTestHTML = `
+<html>
+	<body>
+		<ul>
+			<li>1</li>
+			<li id="b">2</li>
+			<li id="c">3</li>
+		</ul>
+		<div class="item">a</div>
+		<div class="item">b</div>
+		<div class="item">c</div>
+	</body>
+</html>
+`
+
+func xpathTexts(t *testing.T, root *goelement.Node, expr string) []string {
+	t.Helper()
+	nodes, err := root.XPath(expr)
+	if err != nil {
+		t.Fatalf("XPath(%q): %v", expr, err)
+	}
+	var texts []string
+	for _, n := range nodes {
+		texts = append(texts, n.Text())
+	}
+	return texts
+}
+
+func TestXPathAxesAndAbbreviations(t *testing.T) {
+	root := goelement.ParseFromString(xpathTestHTML)
+
+	if got := xpathTexts(t, root, "/html/body/ul/li"); len(got) != 3 {
+		t.Fatalf("absolute path got %v, want 3 <li>", got)
+	}
+	if got := xpathTexts(t, root, "//li"); len(got) != 3 {
+		t.Fatalf("//li got %v, want 3 nodes", got)
+	}
+	if got := xpathTexts(t, root, "//li[@id]/parent::ul/@class"); len(got) != 0 {
+		// ul has no class attribute, so the attribute axis yields nothing;
+		// this exercises parent:: without erroring.
+		t.Fatalf("parent::ul/@class should be empty, got %v", got)
+	}
+}
+
+func TestXPathChainedPredicates(t *testing.T) {
+	root := goelement.ParseFromString(xpathTestHTML)
+
+	got := xpathTexts(t, root, "//li[@id][1]")
+	if len(got) != 1 || got[0] != "2" {
+		t.Fatalf("//li[@id][1] = %v, want the first <li> that has @id (text \"2\")", got)
+	}
+
+	got = xpathTexts(t, root, "//div[@class='item'][position()>1]")
+	if len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("//div[@class='item'][position()>1] = %v, want [b c]", got)
+	}
+}
+
+func TestXPathFunctions(t *testing.T) {
+	root := goelement.ParseFromString(xpathTestHTML)
+
+	result, err := root.XPathEval("count(//li)")
+	if err != nil {
+		t.Fatalf("XPathEval: %v", err)
+	}
+	if result.Number() != 3 {
+		t.Fatalf("count(//li) = %v, want 3", result.Number())
+	}
+
+	result, err = root.XPathEval("//li[contains(text(), '2')]/@id")
+	if err != nil {
+		t.Fatalf("XPathEval: %v", err)
+	}
+	if result.String() != "b" {
+		t.Fatalf("contains()-filtered @id = %q, want \"b\"", result.String())
+	}
+
+	got := xpathTexts(t, root, "//div[starts-with(@class, 'ite')]")
+	if len(got) != 3 {
+		t.Fatalf("starts-with(@class, 'ite') = %v, want all 3 divs", got)
+	}
+
+	result, err = root.XPathEval("normalize-space('  a   b  ')")
+	if err != nil {
+		t.Fatalf("XPathEval: %v", err)
+	}
+	if result.String() != "a b" {
+		t.Fatalf("normalize-space = %q, want \"a b\"", result.String())
+	}
+}
+
+func TestXPathMod(t *testing.T) {
+	root := goelement.ParseFromString(xpathTestHTML)
+
+	result, err := root.XPathEval("5.5 mod 2")
+	if err != nil {
+		t.Fatalf("XPathEval: %v", err)
+	}
+	if result.Number() != 1.5 {
+		t.Fatalf("5.5 mod 2 = %v, want 1.5 (operands must not be truncated to int)", result.Number())
+	}
+
+	result, err = root.XPathEval("5 mod 0")
+	if err != nil {
+		t.Fatalf("XPathEval: %v", err)
+	}
+	if !math.IsNaN(result.Number()) {
+		t.Fatalf("5 mod 0 = %v, want NaN per XPath 1.0, not a divide-by-zero panic", result.Number())
+	}
+}